@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/conductorone/baton-zoho-people/pkg/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/oauth2"
+)
+
+const (
+	authScopesFlag   = "scopes"
+	authListenFlag   = "listen"
+	authCallbackPath = "/callback"
+	// Zoho grant codes expire after ten minutes, there is no point in waiting longer.
+	authCallbackTimeout = 10 * time.Minute
+)
+
+var defaultAuthScopes = []string{"ZOHOPEOPLE.forms.ALL"}
+
+// authCommand exchanges a Zoho grant code for a refresh token that can be used with --zoho-refresh-token.
+func authCommand(ctx context.Context, v *viper.Viper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Exchange a Zoho grant code for a refresh token",
+		Long: "Exchange a Zoho grant code for a refresh token.\n\n" +
+			"The grant code is read from --zoho-code or standard input. With --listen, a loopback\n" +
+			"redirect listener is started instead and the browser authorization flow is completed\n" +
+			"for server-based Zoho OAuth clients.",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := v.BindPFlags(cmd.Flags()); err != nil {
+				return err
+			}
+			return runAuth(ctx, v, cmd.InOrStdin(), cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().String(clientIDField.FieldName, "", "Client ID of the Zoho OAuth client ($BATON_ZOHO_CLIENT_ID)")
+	cmd.Flags().String(secretIDField.FieldName, "", "Client secret of the Zoho OAuth client ($BATON_ZOHO_SECRET_ID)")
+	cmd.Flags().String(domainAccount.FieldName, "US", "The domain specific account to get the access token ($BATON_DOMAIN_ACCOUNT)")
+	cmd.Flags().String(codeField.FieldName, "", "The grant code generated in the API console ($BATON_ZOHO_CODE)")
+	cmd.Flags().String(tokenCachePathField.FieldName, "", "Write the tokens to this encrypted token cache instead of printing the refresh token ($BATON_ZOHO_TOKEN_CACHE_PATH)")
+	cmd.Flags().StringSlice(authScopesFlag, defaultAuthScopes, "Scopes requested in the browser authorization flow")
+	cmd.Flags().String(authListenFlag, "", "Loopback address, e.g. 127.0.0.1:8085, to receive the OAuth redirect on")
+
+	return cmd
+}
+
+func runAuth(ctx context.Context, v *viper.Viper, in io.Reader, out io.Writer) error {
+	authData := client.ZohoAuthData{
+		ClientID:       v.GetString(clientIDField.FieldName),
+		ClientSecret:   v.GetString(secretIDField.FieldName),
+		DomainAccount:  v.GetString(domainAccount.FieldName),
+		TokenCachePath: v.GetString(tokenCachePathField.FieldName),
+	}
+	if authData.ClientID == "" || authData.ClientSecret == "" {
+		return fmt.Errorf("--%s and --%s are required", clientIDField.FieldName, secretIDField.FieldName)
+	}
+	if _, ok := client.TokenURL[authData.DomainAccount]; !ok {
+		return fmt.Errorf("unknown domain account %q", authData.DomainAccount)
+	}
+
+	var (
+		cfg  *oauth2.Config
+		code string
+		err  error
+	)
+
+	if listen := v.GetString(authListenFlag); listen != "" {
+		cfg, code, err = authorizeInBrowser(ctx, authData, listen, v.GetStringSlice(authScopesFlag), out)
+		if err != nil {
+			return err
+		}
+	} else {
+		cfg = client.OAuthConfig(authData, "")
+		code = v.GetString(codeField.FieldName)
+		if code == "" {
+			fmt.Fprint(out, "Grant code: ")
+			code, err = bufio.NewReader(in).ReadString('\n')
+			if err != nil && !errors.Is(err, io.EOF) {
+				return err
+			}
+			code = strings.TrimSpace(code)
+		}
+	}
+
+	if code == "" {
+		return errors.New("no grant code provided")
+	}
+
+	token, err := cfg.Exchange(ctx, code)
+	if err != nil {
+		return fmt.Errorf("error exchanging grant code: %w", err)
+	}
+	if token.RefreshToken == "" {
+		return errors.New("zoho did not return a refresh token, generate a new grant code with offline access")
+	}
+
+	if scope, ok := token.Extra("scope").(string); ok && scope != "" {
+		fmt.Fprintf(out, "Granted scopes: %s\n", scope)
+	}
+	if apiDomain, ok := token.Extra("api_domain").(string); ok && apiDomain != "" {
+		fmt.Fprintf(out, "API domain: %s\n", apiDomain)
+	}
+
+	if authData.TokenCachePath != "" {
+		if err := client.SaveToken(authData, token); err != nil {
+			return fmt.Errorf("error writing token cache: %w", err)
+		}
+		fmt.Fprintf(out, "Tokens written to %s\n", authData.TokenCachePath)
+		return nil
+	}
+
+	fmt.Fprintf(out, "Refresh token: %s\n", token.RefreshToken)
+	return nil
+}
+
+// authorizeInBrowser runs a loopback redirect listener and returns the grant code Zoho redirects to it with.
+func authorizeInBrowser(
+	ctx context.Context,
+	authData client.ZohoAuthData,
+	listen string,
+	scopes []string,
+	out io.Writer,
+) (*oauth2.Config, string, error) {
+	host, _, err := net.SplitHostPort(listen)
+	if err != nil {
+		return nil, "", err
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, "", fmt.Errorf("--%s must be a loopback address, got %s", authListenFlag, listen)
+	}
+
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		return nil, "", err
+	}
+
+	redirectURL := (&url.URL{Scheme: "http", Host: listener.Addr().String(), Path: authCallbackPath}).String()
+	cfg := client.OAuthConfig(authData, redirectURL, scopes...)
+
+	state, err := randomState()
+	if err != nil {
+		listener.Close()
+		return nil, "", err
+	}
+
+	type callbackResult struct {
+		code          string
+		accountServer string
+		err           error
+	}
+	results := make(chan callbackResult, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(authCallbackPath, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var res callbackResult
+		switch {
+		case q.Get("state") != state:
+			res.err = errors.New("state mismatch in OAuth redirect")
+		case q.Get("error") != "":
+			res.err = fmt.Errorf("authorization failed: %s", q.Get("error"))
+		case q.Get("code") == "":
+			res.err = errors.New("OAuth redirect is missing the grant code")
+		default:
+			res.code = q.Get("code")
+			res.accountServer = q.Get("accounts-server")
+		}
+
+		if res.err != nil {
+			http.Error(w, res.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Authorization complete, you can close this window.")
+		}
+
+		select {
+		case results <- res:
+		default:
+		}
+	})
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Close()
+
+	fmt.Fprintf(out, "Open the following URL in your browser and approve the access request:\n\n%s\n\n",
+		cfg.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.SetAuthURLParam("prompt", "consent")))
+
+	ctx, cancel := context.WithTimeout(ctx, authCallbackTimeout)
+	defer cancel()
+
+	select {
+	case res := <-results:
+		if res.err != nil {
+			return nil, "", res.err
+		}
+		// Zoho redirects with the accounts server of the data center the user belongs to.
+		if tokenURL, ok := accountsServerTokenURL(res.accountServer); ok {
+			cfg.Endpoint.TokenURL = tokenURL
+		}
+		return cfg, res.code, nil
+	case <-ctx.Done():
+		return nil, "", fmt.Errorf("waiting for OAuth redirect: %w", ctx.Err())
+	}
+}
+
+// accountsServerTokenURL returns the token endpoint of the accounts server Zoho redirected with.
+// Only the accounts servers of the known data centers are accepted, the grant code and the client
+// secret are sent there.
+func accountsServerTokenURL(accountsServer string) (string, bool) {
+	if accountsServer == "" {
+		return "", false
+	}
+
+	u, err := url.Parse(accountsServer)
+	if err != nil || u.Scheme != "https" || u.User != nil || u.Port() != "" {
+		return "", false
+	}
+
+	for _, domain := range client.TokenURL {
+		if u.Host == "accounts.zoho."+domain {
+			return fmt.Sprintf("https://%s/oauth/v2/token", u.Host), true
+		}
+	}
+
+	return "", false
+}
+
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountsServerTokenURL(t *testing.T) {
+	tests := []struct {
+		accountsServer string
+		tokenURL       string
+	}{
+		{accountsServer: "https://accounts.zoho.com", tokenURL: "https://accounts.zoho.com/oauth/v2/token"},
+		{accountsServer: "https://accounts.zoho.eu", tokenURL: "https://accounts.zoho.eu/oauth/v2/token"},
+		{accountsServer: "https://accounts.zoho.com.au/", tokenURL: "https://accounts.zoho.com.au/oauth/v2/token"},
+		{accountsServer: "https://accounts.zoho.com.cn/some/path", tokenURL: "https://accounts.zoho.com.cn/oauth/v2/token"},
+		{accountsServer: ""},
+		{accountsServer: "http://accounts.zoho.eu"},
+		{accountsServer: "https://accounts.zoho.evil.com"},
+		{accountsServer: "https://accounts.zoho.com.evil.com"},
+		{accountsServer: "https://accounts.zoho.eu.attacker.net"},
+		{accountsServer: "https://evil.com/accounts.zoho.eu"},
+		{accountsServer: "https://accounts.zoho.eu@evil.com"},
+		{accountsServer: "https://user@accounts.zoho.eu"},
+		{accountsServer: "https://accounts.zoho.eu:8443"},
+		{accountsServer: "https://xaccounts.zoho.eu"},
+		{accountsServer: "https://accounts.zoho.de"},
+		{accountsServer: "accounts.zoho.eu"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accountsServer, func(t *testing.T) {
+			tokenURL, ok := accountsServerTokenURL(tt.accountsServer)
+			require.Equal(t, tt.tokenURL != "", ok)
+			require.Equal(t, tt.tokenURL, tokenURL)
+		})
+	}
+}
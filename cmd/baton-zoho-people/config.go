@@ -1,7 +1,12 @@
 package main
 
 import (
+	"fmt"
+	"net/url"
+	"strings"
+
 	"github.com/conductorone/baton-sdk/pkg/field"
+	"github.com/conductorone/baton-zoho-people/pkg/client"
 	"github.com/spf13/viper"
 )
 
@@ -18,9 +23,64 @@ var (
 	)
 	codeField = field.StringField(
 		"zoho-code",
-		field.WithRequired(true),
 		field.WithDescription("The temporary authorization code to access Zoho APIs."),
 	)
+	refreshTokenField = field.StringField(
+		"zoho-refresh-token",
+		field.WithDescription("The refresh token used to obtain access tokens for Zoho APIs."),
+		field.WithIsSecret(true),
+	)
+	tokenCachePathField = field.StringField(
+		"zoho-token-cache-path",
+		field.WithDescription("Path of an encrypted file used to persist Zoho tokens between runs."),
+	)
+	baseURLField = field.StringField(
+		"zoho-base-url",
+		field.WithDescription("Override the Zoho People API host, e.g. https://people.zoho.eu. Defaults to the host of the domain account."),
+	)
+	dailyAPIBudgetField = field.IntField(
+		"zoho-daily-api-budget",
+		field.WithDescription("Maximum number of Zoho People API calls per day. 0 means no limit. The count is shared between runs when --zoho-token-cache-path is set, otherwise it only covers a single run."),
+		field.WithDefaultValue(0),
+	)
+	sensitiveFieldsField = field.StringSliceField(
+		"zoho-sensitive-fields",
+		field.WithDescription("Zoho field labels to strip from API responses, even when they are mapped into the user profile."),
+	)
+	profileFieldsField = field.StringSliceField(
+		"zoho-profile-fields",
+		field.WithDescription("Zoho employee fields to copy into the user profile, as label=profile_key pairs, e.g. Cost_Center=cost_center."),
+	)
+	defaultRoleIDField = field.StringField(
+		"zoho-default-role-id",
+		field.WithDescription("Zoho role ID employees are moved to when their role is revoked."),
+	)
+	fallbackDepartmentIDField = field.StringField(
+		"zoho-fallback-department-id",
+		field.WithDescription("Zoho department ID employees are moved to when their department membership is revoked."),
+	)
+	offboardStatusField = field.SelectField(
+		"zoho-offboard-status",
+		[]string{"Terminated", "Resigned"},
+		field.WithDescription("Employee status set when a user is deleted. Deleting a user marks the employee as exited."),
+		field.WithDefaultValue("Terminated"),
+	)
+	offboardRevokeRoleField = field.BoolField(
+		"zoho-offboard-revoke-role",
+		field.WithDescription("Move offboarded employees to the role set with --zoho-default-role-id."),
+	)
+	offboardClearManagerField = field.BoolField(
+		"zoho-offboard-clear-manager",
+		field.WithDescription("Remove the reporting manager of offboarded employees."),
+	)
+	employeeFiltersField = field.StringSliceField(
+		"zoho-employee-filters",
+		field.WithDescription("Only sync the employees matching all the Field:Operator:Text search criteria, e.g. Employeestatus:Is:Active."),
+	)
+	departmentFiltersField = field.StringSliceField(
+		"zoho-department-filters",
+		field.WithDescription("Only sync the departments matching all the Field:Operator:Text search criteria."),
+	)
 	domainAccount = field.SelectField(
 		"domain-account",
 		[]string{"US", "AU", "EU", "IN", "CN"},
@@ -30,13 +90,34 @@ var (
 	// ConfigurationFields defines the external configuration required for the
 	// connector to run. Note: these fields can be marked as optional or
 	// required.
-	ConfigurationFields = []field.SchemaField{clientIDField, secretIDField, codeField, domainAccount}
+	ConfigurationFields = []field.SchemaField{
+		clientIDField,
+		secretIDField,
+		codeField,
+		refreshTokenField,
+		tokenCachePathField,
+		domainAccount,
+		baseURLField,
+		dailyAPIBudgetField,
+		sensitiveFieldsField,
+		profileFieldsField,
+		defaultRoleIDField,
+		fallbackDepartmentIDField,
+		offboardStatusField,
+		offboardRevokeRoleField,
+		offboardClearManagerField,
+		employeeFiltersField,
+		departmentFiltersField,
+	}
 
 	// FieldRelationships defines relationships between the fields listed in
 	// ConfigurationFields that can be automatically validated. For example, a
 	// username and password can be required together, or an access token can be
 	// marked as mutually exclusive from the username password pair.
-	FieldRelationships = []field.SchemaFieldRelationship{}
+	FieldRelationships = []field.SchemaFieldRelationship{
+		field.FieldsMutuallyExclusive(codeField, refreshTokenField),
+		field.FieldsAtLeastOneUsed(codeField, refreshTokenField, tokenCachePathField),
+	}
 )
 
 // ValidateConfig is run after the configuration is loaded, and should return an
@@ -44,5 +125,45 @@ var (
 // needs to perform extra validations that cannot be encoded with configuration
 // parameters.
 func ValidateConfig(v *viper.Viper) error {
+	if baseURL := v.GetString(baseURLField.FieldName); baseURL != "" {
+		u, err := url.Parse(baseURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("invalid %s %q: expected an absolute http(s) URL", baseURLField.FieldName, baseURL)
+		}
+	}
+
+	if v.GetInt64(dailyAPIBudgetField.FieldName) < 0 {
+		return fmt.Errorf("%s must not be negative", dailyAPIBudgetField.FieldName)
+	}
+
+	if _, err := parseProfileFieldMapping(v.GetStringSlice(profileFieldsField.FieldName)); err != nil {
+		return err
+	}
+
+	for _, filtersField := range []field.SchemaField{employeeFiltersField, departmentFiltersField} {
+		if _, err := client.ParseSearchParams(v.GetStringSlice(filtersField.FieldName)); err != nil {
+			return fmt.Errorf("invalid %s: %w", filtersField.FieldName, err)
+		}
+	}
+
+	if v.GetBool(offboardRevokeRoleField.FieldName) && v.GetString(defaultRoleIDField.FieldName) == "" {
+		return fmt.Errorf("%s requires %s", offboardRevokeRoleField.FieldName, defaultRoleIDField.FieldName)
+	}
+
 	return nil
 }
+
+// parseProfileFieldMapping parses label=profile_key pairs into a map of Zoho field labels to profile keys.
+func parseProfileFieldMapping(entries []string) (map[string]string, error) {
+	mapping := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		label, key, ok := strings.Cut(entry, "=")
+		label, key = strings.TrimSpace(label), strings.TrimSpace(key)
+		if !ok || label == "" || key == "" {
+			return nil, fmt.Errorf("invalid %s entry %q: expected label=profile_key", profileFieldsField.FieldName, entry)
+		}
+		mapping[label] = key
+	}
+
+	return mapping, nil
+}
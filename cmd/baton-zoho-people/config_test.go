@@ -13,5 +13,138 @@ func TestConfigs(t *testing.T) {
 		FieldRelationships...,
 	)
 
-	test.ExerciseTestCases(t, configurationSchema, ValidateConfig, []test.TestCase{})
+	test.ExerciseTestCases(
+		t,
+		configurationSchema,
+		ValidateConfig,
+		[]test.TestCase{
+			{
+				Configs: map[string]string{
+					"zoho-client-id": "client-id",
+					"zoho-secret-id": "secret-id",
+					"zoho-code":      "1000.code",
+				},
+				IsValid: true,
+				Message: "grant code",
+			},
+			{
+				Configs: map[string]string{
+					"zoho-client-id":     "client-id",
+					"zoho-secret-id":     "secret-id",
+					"zoho-refresh-token": "1000.refresh",
+				},
+				IsValid: true,
+				Message: "refresh token",
+			},
+			{
+				Configs: map[string]string{
+					"zoho-client-id":        "client-id",
+					"zoho-secret-id":        "secret-id",
+					"zoho-token-cache-path": "/var/lib/baton/zoho-token",
+				},
+				IsValid: true,
+				Message: "token cache only",
+			},
+			{
+				Configs: map[string]string{
+					"zoho-client-id":     "client-id",
+					"zoho-secret-id":     "secret-id",
+					"zoho-code":          "1000.code",
+					"zoho-refresh-token": "1000.refresh",
+				},
+				IsValid: false,
+				Message: "grant code and refresh token",
+			},
+			{
+				Configs: map[string]string{
+					"zoho-client-id": "client-id",
+					"zoho-secret-id": "secret-id",
+				},
+				IsValid: false,
+				Message: "no credentials",
+			},
+			{
+				Configs: map[string]string{
+					"zoho-client-id":     "client-id",
+					"zoho-secret-id":     "secret-id",
+					"zoho-refresh-token": "1000.refresh",
+					"zoho-base-url":      "http://127.0.0.1:8080",
+				},
+				IsValid: true,
+				Message: "base url override",
+			},
+			{
+				Configs: map[string]string{
+					"zoho-client-id":     "client-id",
+					"zoho-secret-id":     "secret-id",
+					"zoho-refresh-token": "1000.refresh",
+					"zoho-base-url":      "people.zoho.eu",
+				},
+				IsValid: false,
+				Message: "relative base url",
+			},
+			{
+				Configs: map[string]string{
+					"zoho-client-id":      "client-id",
+					"zoho-secret-id":      "secret-id",
+					"zoho-refresh-token":  "1000.refresh",
+					"zoho-profile-fields": "Cost_Center=cost_center",
+				},
+				IsValid: true,
+				Message: "profile field mapping",
+			},
+			{
+				Configs: map[string]string{
+					"zoho-client-id":      "client-id",
+					"zoho-secret-id":      "secret-id",
+					"zoho-refresh-token":  "1000.refresh",
+					"zoho-profile-fields": "Cost_Center",
+				},
+				IsValid: false,
+				Message: "profile field mapping without key",
+			},
+			{
+				Configs: map[string]string{
+					"zoho-client-id":            "client-id",
+					"zoho-secret-id":            "secret-id",
+					"zoho-refresh-token":        "1000.refresh",
+					"zoho-offboard-status":      "Resigned",
+					"zoho-offboard-revoke-role": "true",
+					"zoho-default-role-id":      "858578000000035645",
+				},
+				IsValid: true,
+				Message: "offboarding with role revocation",
+			},
+			{
+				Configs: map[string]string{
+					"zoho-client-id":            "client-id",
+					"zoho-secret-id":            "secret-id",
+					"zoho-refresh-token":        "1000.refresh",
+					"zoho-offboard-revoke-role": "true",
+				},
+				IsValid: false,
+				Message: "offboarding role revocation without default role",
+			},
+			{
+				Configs: map[string]string{
+					"zoho-client-id":        "client-id",
+					"zoho-secret-id":        "secret-id",
+					"zoho-refresh-token":    "1000.refresh",
+					"zoho-employee-filters": "Employeestatus:Is:Active",
+				},
+				IsValid: true,
+				Message: "employee filters",
+			},
+			{
+				Configs: map[string]string{
+					"zoho-client-id":          "client-id",
+					"zoho-secret-id":          "secret-id",
+					"zoho-refresh-token":      "1000.refresh",
+					"zoho-department-filters": "Department:Equals:Sales",
+				},
+				IsValid: false,
+				Message: "department filter with unknown operator",
+			},
+		},
+	)
 }
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/conductorone/baton-sdk/pkg/field"
+	"github.com/conductorone/baton-zoho-people/pkg/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const describeFormFlag = "form"
+
+// describeCommand prints the forms of the organization and their fields, which are the labels
+// --zoho-profile-fields and the sync filters refer to.
+func describeCommand(ctx context.Context, v *viper.Viper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "describe",
+		Short: "List the Zoho People forms and their fields",
+		Long: "List the Zoho People forms and their fields.\n\n" +
+			"Prints the label, type and lookup target of every field. The labels are the names used by\n" +
+			"--zoho-profile-fields, --zoho-employee-filters and --zoho-department-filters.",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := v.BindPFlags(cmd.Flags()); err != nil {
+				return err
+			}
+
+			authData, clientOpts := getClientConfig(v)
+			c, err := client.New(ctx, authData, clientOpts...)
+			if err != nil {
+				return err
+			}
+
+			return runDescribe(ctx, c, v.GetStringSlice(describeFormFlag), cmd.OutOrStdout())
+		},
+	}
+
+	// The connector flags are only registered on the root command, the client needs its own.
+	for _, f := range []field.SchemaField{clientIDField, secretIDField, codeField, refreshTokenField, tokenCachePathField, baseURLField} {
+		cmd.Flags().String(f.FieldName, "", fmt.Sprintf("%s ($BATON_%s)", f.Description, envName(f.FieldName)))
+	}
+	cmd.Flags().String(domainAccount.FieldName, "US", fmt.Sprintf("%s ($BATON_%s)", domainAccount.Description, envName(domainAccount.FieldName)))
+	cmd.Flags().Int64(dailyAPIBudgetField.FieldName, 0, fmt.Sprintf("%s ($BATON_%s)", dailyAPIBudgetField.Description, envName(dailyAPIBudgetField.FieldName)))
+	cmd.Flags().StringSlice(sensitiveFieldsField.FieldName, nil, fmt.Sprintf("%s ($BATON_%s)", sensitiveFieldsField.Description, envName(sensitiveFieldsField.FieldName)))
+	cmd.Flags().StringSlice(describeFormFlag, nil, "Only describe the forms with these link names, e.g. employee")
+
+	return cmd
+}
+
+// envName returns the environment variable suffix of a flag, e.g. ZOHO_CLIENT_ID.
+func envName(flagName string) string {
+	return strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+func runDescribe(ctx context.Context, c *client.ZohoPeopleClient, formLinkNames []string, out io.Writer) error {
+	forms, _, err := c.ListForms(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(formLinkNames) != 0 {
+		wanted := make(map[string]bool, len(formLinkNames))
+		for _, name := range formLinkNames {
+			wanted[name] = true
+		}
+
+		var selected []client.Form
+		for _, form := range forms {
+			if wanted[form.FormLinkName] {
+				selected = append(selected, form)
+				delete(wanted, form.FormLinkName)
+			}
+		}
+		for name := range wanted {
+			return fmt.Errorf("unknown form %q", name)
+		}
+		forms = selected
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FORM\tLABEL\tDISPLAY NAME\tTYPE\tMANDATORY\tLOOKUP")
+	for _, form := range forms {
+		components, _, err := c.ListFormComponents(ctx, form.FormLinkName)
+		if err != nil {
+			return err
+		}
+
+		for _, component := range components {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\t%s\n",
+				form.FormLinkName,
+				component.LabelName,
+				component.DisplayName,
+				component.ComponentType,
+				component.IsMandatory,
+				component.LookupForm(),
+			)
+		}
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-zoho-people/pkg/client"
+	"github.com/conductorone/baton-zoho-people/test"
+	"github.com/conductorone/baton-zoho-people/test/mock"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+// describeForms answers the forms listing and the components of the employee and department forms,
+// and records the requested paths.
+func describeForms(paths *[]string) func(*http.Request) (*http.Response, error) {
+	return func(req *http.Request) (*http.Response, error) {
+		*paths = append(*paths, req.URL.Path)
+
+		switch {
+		case strings.HasSuffix(req.URL.Path, "/employee/components"):
+			return mock.JSONResponse(`{"response":{"result":[
+				{"comptype":"Email","displayname":"Email ID","labelname":"EmailID","ismandatory":true},
+				{"comptype":"Lookup","displayname":"Department","labelname":"Department","lookupFormLinkName":"department"}
+			],"status":0}}`), nil
+		case strings.HasSuffix(req.URL.Path, "/department/components"):
+			return mock.JSONResponse(`{"response":{"result":[
+				{"comptype":"Text","displayname":"Department Name","labelname":"Department","ismandatory":true}
+			],"status":0}}`), nil
+		}
+		return mock.JSONResponse(`{"response":{"result":[
+			{"displayName":"Employee","formLinkName":"employee","iscustom":false,"isVisible":true},
+			{"displayName":"Department","formLinkName":"department","iscustom":false,"isVisible":true}
+		],"status":0}}`), nil
+	}
+}
+
+func TestRunDescribe(t *testing.T) {
+	ctx := context.Background()
+
+	var paths []string
+	var out bytes.Buffer
+	require.NoError(t, runDescribe(ctx, test.NewMockClient(describeForms(&paths)), nil, &out))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 4)
+	require.Equal(t, []string{"FORM", "LABEL", "DISPLAY", "NAME", "TYPE", "MANDATORY", "LOOKUP"}, strings.Fields(lines[0]))
+	require.Equal(t, []string{"employee", "EmailID", "Email", "ID", "Email", "true"}, strings.Fields(lines[1]))
+	require.Equal(t, []string{"employee", "Department", "Department", "Lookup", "false", "department"}, strings.Fields(lines[2]))
+	require.Equal(t, []string{"department", "Department", "Department", "Name", "Text", "true"}, strings.Fields(lines[3]))
+
+	// Only the selected forms are described.
+	paths = nil
+	out.Reset()
+	require.NoError(t, runDescribe(ctx, test.NewMockClient(describeForms(&paths)), []string{"department"}, &out))
+	require.Equal(t, []string{"/people/api/forms", "/people/api/forms/department/components"}, paths)
+	require.NotContains(t, out.String(), "EmailID")
+
+	err := runDescribe(ctx, test.NewMockClient(describeForms(new([]string))), []string{"assets"}, &out)
+	require.ErrorContains(t, err, `unknown form "assets"`)
+}
+
+// Tests that the describe command takes the client configuration as flags.
+func TestDescribeCommand_Flags(t *testing.T) {
+	ctx := context.Background()
+
+	var paths, authorization []string
+	forms := describeForms(&paths)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		authorization = append(authorization, req.Header.Get("Authorization"))
+		resp, _ := forms(req)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.Copy(w, resp.Body)
+	}))
+	defer server.Close()
+
+	authData := client.ZohoAuthData{
+		ClientID:       "client-id",
+		ClientSecret:   "secret-id",
+		TokenCachePath: filepath.Join(t.TempDir(), "tokens"),
+	}
+	require.NoError(t, client.SaveToken(authData, &oauth2.Token{
+		AccessToken:  "cached",
+		RefreshToken: "1000.refresh",
+		Expiry:       time.Now().Add(time.Hour),
+	}))
+
+	cmd := describeCommand(ctx, viper.New())
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{
+		"--zoho-client-id", authData.ClientID,
+		"--zoho-secret-id", authData.ClientSecret,
+		"--zoho-refresh-token", "1000.refresh",
+		"--zoho-token-cache-path", authData.TokenCachePath,
+		"--zoho-base-url", server.URL,
+		"--form", "department",
+	})
+	require.NoError(t, cmd.Execute())
+	require.Contains(t, out.String(), "Department Name")
+	require.Equal(t, []string{"/people/api/forms", "/people/api/forms/department/components"}, paths)
+	require.Equal(t, []string{"Bearer cached", "Bearer cached"}, authorization)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
 	"github.com/conductorone/baton-sdk/pkg/field"
 	"github.com/conductorone/baton-sdk/pkg/types"
+	"github.com/conductorone/baton-zoho-people/pkg/client"
 	connectorSchema "github.com/conductorone/baton-zoho-people/pkg/connector"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
 	"github.com/spf13/viper"
@@ -20,12 +21,13 @@ var version = "dev"
 func main() {
 	ctx := context.Background()
 
-	_, cmd, err := config.DefineConfiguration(
+	v, cmd, err := config.DefineConfiguration(
 		ctx,
 		"baton-zoho-people",
 		getConnector,
 		field.Configuration{
-			Fields: ConfigurationFields,
+			Fields:      ConfigurationFields,
+			Constraints: FieldRelationships,
 		},
 	)
 	if err != nil {
@@ -34,6 +36,8 @@ func main() {
 	}
 
 	cmd.Version = version
+	cmd.AddCommand(authCommand(ctx, v))
+	cmd.AddCommand(describeCommand(ctx, v))
 
 	err = cmd.Execute()
 	if err != nil {
@@ -42,6 +46,28 @@ func main() {
 	}
 }
 
+// getClientConfig returns the Zoho credentials and client options from the configuration.
+func getClientConfig(v *viper.Viper) (client.ZohoAuthData, []client.Option) {
+	authData := client.ZohoAuthData{
+		ClientID:       v.GetString(clientIDField.FieldName),
+		ClientSecret:   v.GetString(secretIDField.FieldName),
+		ClientCode:     v.GetString(codeField.FieldName),
+		RefreshToken:   v.GetString(refreshTokenField.FieldName),
+		DomainAccount:  v.GetString(domainAccount.FieldName),
+		TokenCachePath: v.GetString(tokenCachePathField.FieldName),
+	}
+
+	clientOpts := []client.Option{
+		client.WithDailyCallBudget(v.GetInt64(dailyAPIBudgetField.FieldName)),
+		client.WithSensitiveFields(v.GetStringSlice(sensitiveFieldsField.FieldName)...),
+	}
+	if baseURL := v.GetString(baseURLField.FieldName); baseURL != "" {
+		clientOpts = append(clientOpts, client.WithBaseURL(baseURL))
+	}
+
+	return authData, clientOpts
+}
+
 func getConnector(ctx context.Context, v *viper.Viper) (types.ConnectorServer, error) {
 	l := ctxzap.Extract(ctx)
 
@@ -49,12 +75,35 @@ func getConnector(ctx context.Context, v *viper.Viper) (types.ConnectorServer, e
 		return nil, err
 	}
 
-	zohoClientID := v.GetString(clientIDField.FieldName)
-	zohoSecretID := v.GetString(secretIDField.FieldName)
-	zohoCode := v.GetString(codeField.FieldName)
-	zohoDomainAccount := v.GetString(domainAccount.FieldName)
+	authData, clientOpts := getClientConfig(v)
 
-	connectorBuilder, err := connectorSchema.New(ctx, zohoClientID, zohoSecretID, zohoCode, zohoDomainAccount)
+	profileFields, err := parseProfileFieldMapping(v.GetStringSlice(profileFieldsField.FieldName))
+	if err != nil {
+		return nil, err
+	}
+
+	employeeFilters, err := client.ParseSearchParams(v.GetStringSlice(employeeFiltersField.FieldName))
+	if err != nil {
+		return nil, err
+	}
+	departmentFilters, err := client.ParseSearchParams(v.GetStringSlice(departmentFiltersField.FieldName))
+	if err != nil {
+		return nil, err
+	}
+
+	connectorBuilder, err := connectorSchema.New(ctx, authData,
+		connectorSchema.WithClientOptions(clientOpts...),
+		connectorSchema.WithProfileFieldMapping(profileFields),
+		connectorSchema.WithDefaultRoleID(v.GetString(defaultRoleIDField.FieldName)),
+		connectorSchema.WithFallbackDepartmentID(v.GetString(fallbackDepartmentIDField.FieldName)),
+		connectorSchema.WithOffboarding(
+			v.GetString(offboardStatusField.FieldName),
+			v.GetBool(offboardRevokeRoleField.FieldName),
+			v.GetBool(offboardClearManagerField.FieldName),
+		),
+		connectorSchema.WithEmployeeFilters(employeeFilters...),
+		connectorSchema.WithDepartmentFilters(departmentFilters...),
+	)
 	if err != nil {
 		l.Error("error creating connector", zap.Error(err))
 		return nil, err
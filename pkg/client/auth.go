@@ -0,0 +1,153 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+)
+
+const defaultRedirectURL = "https://www.zoho.com"
+
+// OAuthConfig returns the OAuth2 configuration for the Zoho accounts server of the given domain account.
+// Zoho expects scopes as a single comma separated value.
+func OAuthConfig(authData ZohoAuthData, redirectURL string, scopes ...string) *oauth2.Config {
+	if redirectURL == "" {
+		redirectURL = defaultRedirectURL
+	}
+
+	cfg := &oauth2.Config{
+		ClientID:     authData.ClientID,
+		ClientSecret: authData.ClientSecret,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   fmt.Sprintf(authorizationUrl, TokenURL[authData.DomainAccount]),
+			TokenURL:  fmt.Sprintf(accessTokenUrl, TokenURL[authData.DomainAccount]),
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+		RedirectURL: redirectURL,
+	}
+	if len(scopes) != 0 {
+		cfg.Scopes = []string{strings.Join(scopes, ",")}
+	}
+
+	return cfg
+}
+
+// SaveToken stores the token in the encrypted token cache configured in authData, so the
+// connector can pick it up on its next run.
+func SaveToken(authData ZohoAuthData, token *oauth2.Token) error {
+	if authData.TokenCachePath == "" {
+		return errors.New("baton-zoho-people: token cache path is not set")
+	}
+
+	return newTokenCache(authData.TokenCachePath, authData.ClientID, authData.ClientSecret).Save(token)
+}
+
+// getTokenSource builds the token source used to authenticate against Zoho.
+// A cached token takes precedence, then the refresh token and finally the one-time grant code.
+func getTokenSource(ctx context.Context, authData ZohoAuthData) (oauth2.TokenSource, error) {
+	l := ctxzap.Extract(ctx)
+	cfg := OAuthConfig(authData, "")
+
+	var (
+		cache *tokenCache
+		base  oauth2.TokenSource
+	)
+
+	if authData.TokenCachePath != "" {
+		cache = newTokenCache(authData.TokenCachePath, authData.ClientID, authData.ClientSecret)
+		cached, err := cache.Load()
+		if err != nil {
+			l.Warn("ignoring unreadable Zoho token cache", zap.String("path", authData.TokenCachePath), zap.Error(err))
+		}
+
+		// A refresh token passed explicitly replaces whatever was cached for a previous one.
+		if cached != nil && (authData.RefreshToken == "" || authData.RefreshToken == cached.RefreshToken) {
+			base = cfg.TokenSource(ctx, cached)
+		}
+	}
+
+	if base == nil {
+		switch {
+		case authData.RefreshToken != "":
+			base = cfg.TokenSource(ctx, &oauth2.Token{RefreshToken: authData.RefreshToken})
+		case authData.ClientCode != "":
+			base = &codeExchangeTokenSource{
+				ctx:  ctx,
+				cfg:  cfg,
+				code: authData.ClientCode,
+			}
+		default:
+			return nil, errors.New("baton-zoho-people: either a refresh token, a grant code or a token cache is required")
+		}
+	}
+
+	if cache == nil {
+		return base, nil
+	}
+
+	return &cachingTokenSource{
+		ctx:   ctx,
+		base:  base,
+		cache: cache,
+	}, nil
+}
+
+// codeExchangeTokenSource exchanges the grant code once and refreshes the resulting token afterwards.
+type codeExchangeTokenSource struct {
+	ctx       context.Context
+	cfg       *oauth2.Config
+	code      string
+	mu        sync.Mutex
+	refresher oauth2.TokenSource
+}
+
+func (s *codeExchangeTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.refresher != nil {
+		return s.refresher.Token()
+	}
+
+	token, err := s.cfg.Exchange(s.ctx, s.code)
+	if err != nil {
+		return nil, fmt.Errorf("baton-zoho-people: error exchanging grant code: %w", err)
+	}
+
+	s.refresher = s.cfg.TokenSource(s.ctx, token)
+	return token, nil
+}
+
+// cachingTokenSource writes every newly issued token to the token cache.
+type cachingTokenSource struct {
+	ctx   context.Context
+	base  oauth2.TokenSource
+	cache *tokenCache
+	mu    sync.Mutex
+	last  string
+}
+
+func (s *cachingTokenSource) Token() (*oauth2.Token, error) {
+	token, err := s.base.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if token.AccessToken != s.last {
+		if err := s.cache.Save(token); err != nil {
+			ctxzap.Extract(s.ctx).Warn("error saving Zoho token cache", zap.String("path", s.cache.file.path), zap.Error(err))
+		}
+		s.last = token.AccessToken
+	}
+
+	return token, nil
+}
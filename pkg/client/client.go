@@ -1,137 +1,239 @@
 package client
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/ratelimit"
 	"github.com/conductorone/baton-sdk/pkg/uhttp"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
 	"golang.org/x/oauth2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type ZohoPeopleClient struct {
-	wrapper     *uhttp.BaseHttpClient
-	TokenSource oauth2.TokenSource
+	wrapper       *uhttp.BaseHttpClient
+	TokenSource   oauth2.TokenSource
+	baseURL       string
+	domainAccount string
+	limiter       *rateLimiter
+	redactor      *redactor
 }
 
 type ZohoAuthData struct {
-	ClientID      string
-	ClientSecret  string
-	ClientCode    string
-	DomainAccount string
+	ClientID       string
+	ClientSecret   string
+	ClientCode     string
+	RefreshToken   string
+	DomainAccount  string
+	TokenCachePath string
 }
 
 type Option func(client *ZohoPeopleClient)
 
+// WithTokenSource replaces the token source built from the auth data.
+func WithTokenSource(tokenSource oauth2.TokenSource) Option {
+	return func(client *ZohoPeopleClient) {
+		client.TokenSource = tokenSource
+	}
+}
+
+// WithBaseURL points the client at an explicit People API host, e.g. a sandbox org or a local stand-in server.
+func WithBaseURL(baseURL string) Option {
+	return func(client *ZohoPeopleClient) {
+		client.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithDomainAccount selects the data center used when the token response does not carry an api_domain.
+func WithDomainAccount(domainAccount string) Option {
+	return func(client *ZohoPeopleClient) {
+		client.domainAccount = domainAccount
+	}
+}
+
 const (
-	baseUrl        = "https://people.zoho.com/people/api/forms"
-	accessTokenUrl = "https://accounts.zoho.%s/oauth/v2/token" // #nosec
+	peopleUrl        = "https://people.zoho.%s"
+	formsPath        = "/people/api/forms"
+	accessTokenUrl   = "https://accounts.zoho.%s/oauth/v2/token" // #nosec
+	authorizationUrl = "https://accounts.zoho.%s/oauth/v2/auth"
 
 	getDepartmentRecords    = "/department/getRecords"
 	getDepartmentByRecordId = "/department/getDataByID"
 	getEmployeeRecords      = "/employee/getRecords"
 	getEmployeeByRecordId   = "/employee/getDataByID"
+	updateEmployeeRecord    = "/json/employee/updateRecord"
+	insertEmployeeRecord    = "/json/employee/insertRecord"
+
+	viewEmployeePhotoPath = "/api/viewEmployeePhoto"
+
+	formComponentsPath = "/%s/components"
+)
+
+// Link names of the forms the connector syncs.
+const (
+	EmployeeForm   = "employee"
+	DepartmentForm = "department"
 )
 
-func New(ctx context.Context, authData ZohoAuthData, authToken ...oauth2.TokenSource) (*ZohoPeopleClient, error) {
+func New(ctx context.Context, authData ZohoAuthData, opts ...Option) (*ZohoPeopleClient, error) {
 	httpClient, err := uhttp.NewClient(ctx, uhttp.WithLogger(true, ctxzap.Extract(ctx)))
 	if err != nil {
 		return nil, err
 	}
 
-	cli, err := uhttp.NewBaseHttpClientWithContext(context.Background(), httpClient)
+	limiter := newRateLimiter(0)
+	if authData.TokenCachePath != "" {
+		limiter.store = newEncryptedFile(authData.TokenCachePath+budgetFileSuffix, authData.ClientID, authData.ClientSecret)
+	}
+	redactor := newRedactor()
+
+	cli, err := uhttp.NewBaseHttpClientWithContext(context.Background(), withZohoTransport(httpClient, limiter, redactor))
 	if err != nil {
 		return nil, err
 	}
 
 	client := ZohoPeopleClient{
-		wrapper: cli,
+		wrapper:       cli,
+		domainAccount: authData.DomainAccount,
+		limiter:       limiter,
+		redactor:      redactor,
+	}
+
+	for _, opt := range opts {
+		opt(&client)
 	}
 
-	if authToken != nil {
-		client.TokenSource = authToken[0]
-	} else {
-		client.TokenSource = getTokenSource(ctx, authData.ClientID, authData.ClientSecret, authData.ClientCode, authData.DomainAccount)
+	if client.TokenSource == nil {
+		client.TokenSource, err = getTokenSource(ctx, authData)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return &client, nil
 }
 
-func NewClient(tokenSource oauth2.TokenSource, httpClient ...*uhttp.BaseHttpClient) *ZohoPeopleClient {
-	var wrapper = &uhttp.BaseHttpClient{}
-	if httpClient != nil || len(httpClient) != 0 {
-		wrapper = httpClient[0]
+func NewClient(tokenSource oauth2.TokenSource, httpClient *uhttp.BaseHttpClient, opts ...Option) *ZohoPeopleClient {
+	if httpClient == nil {
+		httpClient = &uhttp.BaseHttpClient{}
 	}
-	return &ZohoPeopleClient{
-		wrapper:     wrapper,
+	client := &ZohoPeopleClient{
 		TokenSource: tokenSource,
+		limiter:     newRateLimiter(0),
+		redactor:    newRedactor(),
+	}
+
+	// Wrap a copy, the http client belongs to the caller.
+	wrapper := *httpClient
+	if wrapper.HttpClient != nil {
+		wrapper.HttpClient = withZohoTransport(wrapper.HttpClient, client.limiter, client.redactor)
 	}
+	client.wrapper = &wrapper
+
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	return client
 }
 
-func (c *ZohoPeopleClient) ListUsers(ctx context.Context, options PageOptions) ([]Employee, string, annotations.Annotations, error) {
-	l := ctxzap.Extract(ctx)
-	var res EmployeeResponse
-	var annotation annotations.Annotations
+// withZohoTransport returns a copy of the http client whose transport handles Zoho throttling and
+// strips the fields that are not allowed from responses.
+func withZohoTransport(httpClient *http.Client, limiter *rateLimiter, redactor *redactor) *http.Client {
+	wrapped := *httpClient
+	wrapped.Transport = &throttleTransport{
+		base:    &redactTransport{base: httpClient.Transport, redactor: redactor},
+		limiter: limiter,
+	}
+	return &wrapped
+}
 
-	queryUrl, err := url.JoinPath(baseUrl, getEmployeeRecords)
-	if err != nil {
-		l.Error(fmt.Sprintf("Error creating url: %s", err))
-		return nil, "", nil, err
+// getFormsURL returns the forms API endpoint on the People host of the account's data center.
+func (c *ZohoPeopleClient) getFormsURL(endpoint string) (string, error) {
+	return url.JoinPath(c.getPeopleURL(), formsPath, endpoint)
+}
+
+// getPeopleURL resolves the People API host. An explicit base URL wins, then the data center
+// reported in the token's api_domain and finally the configured domain account.
+func (c *ZohoPeopleClient) getPeopleURL() string {
+	if c.baseURL != "" {
+		return c.baseURL
 	}
 
-	annotation, err = c.getResourcesFromAPI(ctx, queryUrl, &res, WithPageIndex(options.PageToken), WithPageLimit(options.PageSize))
-	if err != nil {
-		l.Error(fmt.Sprintf("Error getting resources: %s", err))
-		return nil, "", nil, err
+	domain, ok := TokenURL[c.domainAccount]
+	if !ok {
+		domain = TokenURL["US"]
 	}
 
-	result := res.Response.Result
-	if result != nil {
-		var employees []Employee
-		for _, item := range result {
-			for _, empList := range item {
-				employees = append(employees, empList...)
-			}
+	if token, err := c.TokenSource.Token(); err == nil {
+		if apiDomain, ok := domainFromAPIDomain(tokenExtra(token, "api_domain")); ok {
+			domain = apiDomain
 		}
-		return employees, getNextPageToken(options.PageToken, options.PageSize, len(result)), annotation, nil
-	} else {
-		return nil, "", annotation, nil
 	}
+
+	return fmt.Sprintf(peopleUrl, domain)
 }
 
+// ListUsers returns a page of the Employee form.
+func (c *ZohoPeopleClient) ListUsers(ctx context.Context, options PageOptions) ([]Employee, string, annotations.Annotations, error) {
+	return listRecords[Employee](ctx, c, getEmployeeRecords, EmployeeForm, options)
+}
+
+// ListDepartments returns a page of the Department form.
 func (c *ZohoPeopleClient) ListDepartments(ctx context.Context, options PageOptions) ([]Department, string, annotations.Annotations, error) {
+	return listRecords[Department](ctx, c, getDepartmentRecords, DepartmentForm, options)
+}
+
+// listRecords reads a page of a getRecords listing and returns the token of the next page.
+func listRecords[T any](
+	ctx context.Context,
+	c *ZohoPeopleClient,
+	endpoint string,
+	form string,
+	options PageOptions,
+) ([]T, string, annotations.Annotations, error) {
 	l := ctxzap.Extract(ctx)
-	var res DepartmentResponse
-	var annotation annotations.Annotations
+	var res recordsResponse
 
-	queryUrl, err := url.JoinPath(baseUrl, getDepartmentRecords)
+	cursor, err := newPageCursor(options)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	queryUrl, err := c.getFormsURL(endpoint)
 	if err != nil {
 		l.Error(fmt.Sprintf("Error creating url: %s", err))
 		return nil, "", nil, err
 	}
 
-	annotation, err = c.getResourcesFromAPI(ctx, queryUrl, &res, WithPageIndex(options.PageToken), WithPageLimit(options.PageSize))
+	reqOptions := append(cursor.reqOptions(),
+		WithSearchParams(options.Search),
+	)
+	annotation, err := c.getResourcesFromAPI(ctx, queryUrl, &res, reqOptions...)
 	if err != nil {
+		// Zoho answers a page past the last record with an error instead of an empty result.
+		if IsNoRecords(err) {
+			return nil, "", annotation, nil
+		}
 		l.Error(fmt.Sprintf("Error getting resources: %s", err))
 		return nil, "", nil, err
 	}
 
 	result := res.Response.Result
-	if result != nil {
-		var departments []Department
-		for _, item := range result {
-			for _, empList := range item {
-				departments = append(departments, empList...)
-			}
-		}
-		return departments, getNextPageToken(options.PageToken, options.PageSize, len(result)), annotation, nil
-	} else {
-		return nil, "", annotation, nil
-	}
+	records := decodeRecords[T](result, skipRecord(ctx, form))
+
+	return records, cursor.next(countRecords(result)), annotation, nil
 }
 
 func (c *ZohoPeopleClient) GetDepartmentByID(ctx context.Context, departmentID string) ([]Department, string, annotations.Annotations, error) {
@@ -140,7 +242,7 @@ func (c *ZohoPeopleClient) GetDepartmentByID(ctx context.Context, departmentID s
 	var departments []Department
 	var annotation annotations.Annotations
 
-	queryUrl, err := url.JoinPath(baseUrl, getDepartmentByRecordId)
+	queryUrl, err := c.getFormsURL(getDepartmentByRecordId)
 	if err != nil {
 		l.Error(fmt.Sprintf("Error creating url: %s", err))
 		return departments, "", nil, err
@@ -165,7 +267,7 @@ func (c *ZohoPeopleClient) GetEmployeeByID(ctx context.Context, employeeID strin
 	var employees []Employee
 	var annotation annotations.Annotations
 
-	queryUrl, err := url.JoinPath(baseUrl, getEmployeeByRecordId)
+	queryUrl, err := c.getFormsURL(getEmployeeByRecordId)
 	if err != nil {
 		l.Error(fmt.Sprintf("Error creating url: %s", err))
 		return employees, "", nil, err
@@ -184,6 +286,214 @@ func (c *ZohoPeopleClient) GetEmployeeByID(ctx context.Context, employeeID strin
 	return employees, "", annotation, nil
 }
 
+// ListForms returns the forms of the organization.
+func (c *ZohoPeopleClient) ListForms(ctx context.Context) ([]Form, annotations.Annotations, error) {
+	var res FormsResponse
+
+	queryUrl, err := c.getFormsURL("")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	annotation, err := c.getResourcesFromAPI(ctx, queryUrl, &res)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-zoho-people: error listing forms: %w", err)
+	}
+
+	return res.Response.Result, annotation, nil
+}
+
+// ListFormComponents returns the fields of the form with the given link name, e.g. "employee".
+func (c *ZohoPeopleClient) ListFormComponents(ctx context.Context, formLinkName string) ([]FormComponent, annotations.Annotations, error) {
+	var res FormComponentsResponse
+
+	queryUrl, err := c.getFormsURL(fmt.Sprintf(formComponentsPath, formLinkName))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	annotation, err := c.getResourcesFromAPI(ctx, queryUrl, &res)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-zoho-people: error listing fields of form %s: %w", formLinkName, err)
+	}
+
+	return res.Response.Result, annotation, nil
+}
+
+// UpdateEmployee sets the given fields, keyed by Zoho field label, on the employee record.
+// Lookup fields such as Role or Department take the ID of the referenced record.
+func (c *ZohoPeopleClient) UpdateEmployee(ctx context.Context, employeeID string, fields map[string]string) (annotations.Annotations, error) {
+	var res RecordResponse
+
+	inputData, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	queryUrl, err := c.getFormsURL(updateEmployeeRecord)
+	if err != nil {
+		return nil, err
+	}
+
+	_, annotation, err := c.doRequest(ctx, http.MethodPost, queryUrl, &res,
+		WithQueryParam("recordId", employeeID),
+		WithQueryParam("inputData", string(inputData)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("baton-zoho-people: error updating employee %s: %w", employeeID, err)
+	}
+
+	c.clearCaches(ctx)
+
+	return annotation, nil
+}
+
+// InsertEmployee adds an employee record with the given fields, keyed by Zoho field label,
+// and returns the ID of the new record.
+func (c *ZohoPeopleClient) InsertEmployee(ctx context.Context, fields map[string]string) (string, annotations.Annotations, error) {
+	var res RecordResponse
+
+	inputData, err := json.Marshal(fields)
+	if err != nil {
+		return "", nil, err
+	}
+
+	queryUrl, err := c.getFormsURL(insertEmployeeRecord)
+	if err != nil {
+		return "", nil, err
+	}
+
+	_, annotation, err := c.doRequest(ctx, http.MethodPost, queryUrl, &res, WithQueryParam("inputData", string(inputData)))
+	if err != nil {
+		return "", nil, fmt.Errorf("baton-zoho-people: error adding employee: %w", err)
+	}
+
+	c.clearCaches(ctx)
+
+	recordID := strings.Trim(string(res.Response.Result.PkID), `"`)
+	if recordID == "" || recordID == "null" {
+		return "", annotation, fmt.Errorf("baton-zoho-people: zoho did not return the ID of the new employee: %s", res.Response.Message)
+	}
+
+	return recordID, annotation, nil
+}
+
+// clearCaches drops the cached GET responses after a write, so the next read sees the change.
+func (c *ZohoPeopleClient) clearCaches(ctx context.Context) {
+	if err := uhttp.ClearCaches(ctx); err != nil {
+		ctxzap.Extract(ctx).Warn("error clearing the http cache", zap.Error(err))
+	}
+}
+
+// GetEmployeePhoto downloads the employee photo stored under the given file name, as found in
+// the filename parameter of Photo_downloadUrl. It returns the content type and the image, which
+// the caller must close.
+func (c *ZohoPeopleClient) GetEmployeePhoto(ctx context.Context, fileName string) (string, io.ReadCloser, error) {
+	photoURL, err := url.Parse(c.getPeopleURL() + viewEmployeePhotoPath)
+	if err != nil {
+		return "", nil, err
+	}
+	WithQueryParam("filename", fileName)(photoURL)
+
+	var (
+		contentType string
+		photo       io.ReadCloser
+	)
+	err = c.withRetry(ctx, http.MethodGet, photoURL.String(), func() error {
+		contentType, photo, err = c.getEmployeePhotoOnce(ctx, photoURL, fileName)
+		return err
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return contentType, photo, nil
+}
+
+func (c *ZohoPeopleClient) getEmployeePhotoOnce(ctx context.Context, photoURL *url.URL, fileName string) (string, io.ReadCloser, error) {
+	if err := c.limiter.acquire(ctx); err != nil {
+		return "", nil, err
+	}
+
+	authToken, err := c.TokenSource.Token()
+	if err != nil {
+		return "", nil, uhttp.WrapErrors(codes.Unauthenticated, "error getting Zoho access token", err)
+	}
+
+	req, err := c.wrapper.NewRequest(ctx, http.MethodGet, photoURL)
+	if err != nil {
+		return "", nil, err
+	}
+	authToken.SetAuthHeader(req)
+
+	// The photo goes straight through the http client: uhttp reads the whole body into memory and
+	// keeps GET responses in its response cache, which is meant for API data.
+	resp, err := c.wrapper.HttpClient.Do(req)
+	if err != nil {
+		return "", nil, err
+	}
+
+	body := bufio.NewReader(resp.Body)
+	photo := &photoReader{Reader: body, Closer: resp.Body}
+
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if resp.StatusCode == http.StatusOK && (contentType == "" || contentType == "application/octet-stream") {
+		sniff, _ := body.Peek(sniffLen)
+		contentType, _, _ = mime.ParseMediaType(http.DetectContentType(sniff))
+	}
+	if resp.StatusCode == http.StatusOK && strings.HasPrefix(contentType, "image/") {
+		return contentType, photo, nil
+	}
+
+	defer photo.Close()
+	data, err := io.ReadAll(io.LimitReader(body, maxErrorBodySize))
+	if err != nil {
+		return "", nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, httpStatusError(resp, data)
+	}
+	if err := parseZohoError(data); err != nil {
+		return "", nil, err
+	}
+
+	return "", nil, status.Errorf(codes.NotFound, "baton-zoho-people: no photo found for %s, got %s", fileName, contentType)
+}
+
+const (
+	// sniffLen is the number of bytes http.DetectContentType looks at.
+	sniffLen = 512
+	// maxErrorBodySize caps how much of a failed photo response is read.
+	maxErrorBodySize = 64 << 10
+)
+
+// photoReader streams the photo while the content type was sniffed from its first bytes.
+type photoReader struct {
+	io.Reader
+	io.Closer
+}
+
+// httpStatusError maps a failed response to the status uhttp reports for it, keeping the Zoho error.
+func httpStatusError(resp *http.Response, body []byte) error {
+	code := codes.Unknown
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError:
+		code = codes.Unavailable
+	case resp.StatusCode == http.StatusNotFound:
+		code = codes.NotFound
+	case resp.StatusCode == http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case resp.StatusCode == http.StatusForbidden:
+		code = codes.PermissionDenied
+	}
+
+	errs := []error{fmt.Errorf("unexpected status code: %d", resp.StatusCode)}
+	if err := parseZohoError(body); err != nil {
+		errs = append(errs, err)
+	}
+	return uhttp.WrapErrorsWithRateLimitInfo(code, resp, errs...)
+}
+
 func (c *ZohoPeopleClient) getResourcesFromAPI(
 	ctx context.Context,
 	urlAddress string,
@@ -199,6 +509,7 @@ func (c *ZohoPeopleClient) getResourcesFromAPI(
 	return annotation, nil
 }
 
+// doRequest sends the request, retrying idempotent GETs with backoff while Zoho throttles the client.
 func (c *ZohoPeopleClient) doRequest(
 	ctx context.Context,
 	method string,
@@ -206,6 +517,54 @@ func (c *ZohoPeopleClient) doRequest(
 	res interface{},
 	reqOptions ...ReqOpt,
 ) (http.Header, annotations.Annotations, error) {
+	var (
+		header     http.Header
+		annotation annotations.Annotations
+	)
+	err := c.withRetry(ctx, method, endpointUrl, func() error {
+		var err error
+		header, annotation, err = c.doRequestOnce(ctx, method, endpointUrl, res, reqOptions...)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return header, annotation, nil
+}
+
+// withRetry runs do, retrying idempotent GETs with backoff while Zoho throttles the client.
+func (c *ZohoPeopleClient) withRetry(ctx context.Context, method string, endpointUrl string, do func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := do()
+		if err == nil || method != http.MethodGet || !isThrottled(err) {
+			return err
+		}
+
+		wait := c.limiter.retryWait(attempt)
+		if attempt >= maxRetries || wait > maxRetryWait {
+			return c.limiter.lockOutError(err)
+		}
+
+		ctxzap.Extract(ctx).Warn("zoho people API throttled, retrying",
+			zap.String("url", endpointUrl), zap.Int("attempt", attempt+1), zap.Duration("wait", wait), zap.Error(err))
+		if err := c.limiter.sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *ZohoPeopleClient) doRequestOnce(
+	ctx context.Context,
+	method string,
+	endpointUrl string,
+	res interface{},
+	reqOptions ...ReqOpt,
+) (http.Header, annotations.Annotations, error) {
+	if err := c.limiter.acquire(ctx); err != nil {
+		return nil, nil, err
+	}
+
 	var (
 		resp *http.Response
 		err  error
@@ -223,7 +582,7 @@ func (c *ZohoPeopleClient) doRequest(
 
 	authToken, err := c.TokenSource.Token()
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, uhttp.WrapErrors(codes.Unauthenticated, "error getting Zoho access token", err)
 	}
 
 	req, err := c.wrapper.NewRequest(
@@ -233,15 +592,15 @@ func (c *ZohoPeopleClient) doRequest(
 		uhttp.WithContentTypeJSONHeader(),
 		uhttp.WithAcceptJSONHeader(),
 	)
-	authToken.SetAuthHeader(req)
-
 	if err != nil {
 		return nil, nil, err
 	}
 
+	authToken.SetAuthHeader(req)
+
 	switch method {
 	case http.MethodGet, http.MethodPut, http.MethodPost:
-		var doOptions []uhttp.DoOption
+		doOptions := []uhttp.DoOption{withZohoErrorResponse()}
 		if res != nil {
 			doOptions = append(doOptions, uhttp.WithResponse(&res))
 		}
@@ -250,7 +609,7 @@ func (c *ZohoPeopleClient) doRequest(
 			defer resp.Body.Close()
 		}
 	case http.MethodDelete:
-		resp, err = c.wrapper.Do(req)
+		resp, err = c.wrapper.Do(req, withZohoErrorResponse())
 		if resp != nil {
 			defer resp.Body.Close()
 		}
@@ -262,7 +621,9 @@ func (c *ZohoPeopleClient) doRequest(
 
 	annotation := annotations.Annotations{}
 	if resp != nil {
-		if desc, err := ratelimit.ExtractRateLimitData(resp.StatusCode, &resp.Header); err == nil {
+		if desc := c.limiter.description(); desc != nil {
+			annotation.WithRateLimiting(desc)
+		} else if desc, err := ratelimit.ExtractRateLimitData(resp.StatusCode, &resp.Header); err == nil {
 			annotation.WithRateLimiting(desc)
 		} else {
 			return nil, annotation, err
@@ -0,0 +1,196 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-zoho-people/test/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestZohoPeopleClient_getFormsURL(t *testing.T) {
+	euToken := (&oauth2.Token{AccessToken: "token"}).WithExtra(map[string]interface{}{
+		"api_domain": "https://www.zohoapis.eu",
+	})
+
+	tests := []struct {
+		name     string
+		token    *oauth2.Token
+		opts     []Option
+		expected string
+	}{
+		{
+			name:     "default data center",
+			token:    &oauth2.Token{AccessToken: "token"},
+			expected: "https://people.zoho.com/people/api/forms/employee/getRecords",
+		},
+		{
+			name:     "domain account",
+			token:    &oauth2.Token{AccessToken: "token"},
+			opts:     []Option{WithDomainAccount("AU")},
+			expected: "https://people.zoho.com.au/people/api/forms/employee/getRecords",
+		},
+		{
+			name:     "api domain of the token wins over the domain account",
+			token:    euToken,
+			opts:     []Option{WithDomainAccount("US")},
+			expected: "https://people.zoho.eu/people/api/forms/employee/getRecords",
+		},
+		{
+			name:     "explicit base url",
+			token:    euToken,
+			opts:     []Option{WithBaseURL("http://127.0.0.1:8080/")},
+			expected: "http://127.0.0.1:8080/people/api/forms/employee/getRecords",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(oauth2.StaticTokenSource(tt.token), nil, tt.opts...)
+			actual, err := c.getFormsURL(getEmployeeRecords)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestZohoPeopleClient_GetEmployeePhoto(t *testing.T) {
+	pngHeader := "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+
+	tests := []struct {
+		name         string
+		responseType string
+		body         string
+		contentType  string
+		code         codes.Code
+	}{
+		{
+			name:         "declared content type",
+			responseType: "image/jpeg",
+			body:         "\xff\xd8\xff\xe0",
+			contentType:  "image/jpeg",
+		},
+		{
+			name:         "sniffed content type",
+			responseType: "application/octet-stream",
+			body:         pngHeader,
+			contentType:  "image/png",
+		},
+		{
+			name:         "no photo",
+			responseType: "text/html",
+			body:         "<html></html>",
+			code:         codes.NotFound,
+		},
+		{
+			name:         "error envelope",
+			responseType: "application/json",
+			body:         `{"response":{"message":"Error occurred","errors":{"code":7300,"message":"Permission denied"},"status":1}}`,
+			code:         codes.PermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var request *http.Request
+			c := NewClient(
+				oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"}),
+				mock.HTTPClient(func(req *http.Request) (*http.Response, error) {
+					request = req
+					return mock.Response(tt.responseType, tt.body), nil
+				}),
+			)
+
+			contentType, photo, err := c.GetEmployeePhoto(context.Background(), "example1")
+			if tt.code != codes.OK {
+				require.Equal(t, tt.code, status.Code(err))
+				return
+			}
+
+			require.NoError(t, err)
+			defer photo.Close()
+			data, err := io.ReadAll(photo)
+			require.NoError(t, err)
+			require.Equal(t, tt.contentType, contentType)
+			require.Equal(t, tt.body, string(data))
+			require.Equal(t, "https://people.zoho.com/api/viewEmployeePhoto?filename=example1", request.URL.String())
+			require.Equal(t, "Bearer token", request.Header.Get("Authorization"))
+		})
+	}
+}
+
+// Tests that throttled photo requests are retried and that photos are fetched without the response cache.
+func TestZohoPeopleClient_GetEmployeePhotoRetries(t *testing.T) {
+	ctx := context.Background()
+
+	calls := 0
+	c := NewClient(
+		oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"}),
+		mock.HTTPClient(func(*http.Request) (*http.Response, error) {
+			calls++
+			if calls == 1 {
+				return mock.JSONResponse(throttledBody), nil
+			}
+			return mock.Response("image/jpeg", "\xff\xd8\xff\xe0"), nil
+		}),
+	)
+	var waits []time.Duration
+	c.limiter.sleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		c.limiter.lockedUntil = time.Time{}
+		return nil
+	}
+
+	for range 2 {
+		contentType, photo, err := c.GetEmployeePhoto(ctx, "example1")
+		require.NoError(t, err)
+		require.Equal(t, "image/jpeg", contentType)
+		require.NoError(t, photo.Close())
+	}
+	require.Len(t, waits, 1)
+	require.Equal(t, 3, calls)
+}
+
+func TestZohoPeopleClient_FormsMetadata(t *testing.T) {
+	ctx := context.Background()
+
+	var paths []string
+	c := NewClient(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"}),
+		mock.HTTPClient(func(req *http.Request) (*http.Response, error) {
+			paths = append(paths, req.URL.Path)
+			if strings.HasSuffix(req.URL.Path, "/components") {
+				return mock.JSONResponse(`{"response":{"result":[
+					{"comptype":"Email","displayname":"Email ID","labelname":"EmailID","ismandatory":true,"maxLength":100},
+					{"comptype":"Lookup","displayname":"Department","labelname":"Department","ismandatory":false,"lookupFormLinkName":"department"}
+				],"status":0}}`), nil
+			}
+			return mock.JSONResponse(`{"response":{"result":[
+				{"displayName":"Employee","formLinkName":"employee","iscustom":false,"isVisible":true},
+				{"displayName":"Assets","formLinkName":"assets","iscustom":true,"isVisible":true}
+			],"status":0}}`), nil
+		}))
+
+	forms, _, err := c.ListForms(ctx)
+	require.NoError(t, err)
+	require.Len(t, forms, 2)
+	require.Equal(t, "employee", forms[0].FormLinkName)
+	require.True(t, forms[1].IsCustom)
+
+	components, _, err := c.ListFormComponents(ctx, EmployeeForm)
+	require.NoError(t, err)
+	require.Len(t, components, 2)
+	require.Equal(t, "EmailID", components[0].LabelName)
+	require.True(t, components[0].IsMandatory)
+	require.Empty(t, components[0].LookupForm())
+	require.Equal(t, "Lookup", components[1].ComponentType)
+	require.Equal(t, "department", components[1].LookupForm())
+
+	require.Equal(t, []string{"/people/api/forms", "/people/api/forms/employee/components"}, paths)
+}
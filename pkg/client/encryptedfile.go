@@ -0,0 +1,105 @@
+package client
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// encryptedFile stores a document on disk encrypted with AES-GCM using a key derived from the
+// OAuth client credentials. Writes atomically replace the file.
+type encryptedFile struct {
+	path string
+	key  []byte
+}
+
+func newEncryptedFile(path, clientID, clientSecret string) *encryptedFile {
+	key := sha256.Sum256([]byte(clientID + ":" + clientSecret))
+	return &encryptedFile{
+		path: path,
+		key:  key[:],
+	}
+}
+
+// read returns the decrypted content, or nil when the file does not exist yet.
+func (f *encryptedFile) read() ([]byte, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return f.decrypt(data)
+}
+
+func (f *encryptedFile) write(plaintext []byte) error {
+	ciphertext, err := f.encrypt(plaintext)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(f.path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(ciphertext); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), f.path)
+}
+
+func (f *encryptedFile) encrypt(plaintext []byte) ([]byte, error) {
+	gcm, err := f.aead()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (f *encryptedFile) decrypt(ciphertext []byte) ([]byte, error) {
+	gcm, err := f.aead()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("baton-zoho-people: encrypted file is corrupted")
+	}
+
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, sealed, nil)
+}
+
+func (f *encryptedFile) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(f.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
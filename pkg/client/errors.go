@@ -0,0 +1,149 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Error codes documented by Zoho People for the forms API.
+const (
+	ErrCodeInvalidFormName   = 7011
+	ErrCodeInvalidViewName   = 7012
+	ErrCodeInvalidFieldName  = 7013
+	ErrCodeNoRecords         = 7024
+	ErrCodeInvalidSearch     = 7042
+	ErrCodeInvalidToken      = 7202
+	ErrCodeAuthFailure       = 7204
+	ErrCodeInvalidOAuthScope = 7218
+	ErrCodePermissionDenied  = 7300
+)
+
+// responseStatusFailure is the value of response.status when Zoho People reports an error.
+const responseStatusFailure = 1
+
+// ZohoError is an error reported by Zoho People inside the response envelope. Zoho usually
+// answers with HTTP 200 and sets response.status to 1, so the HTTP status code cannot be trusted.
+type ZohoError struct {
+	Code    int
+	Message string
+	URI     string
+}
+
+func (e *ZohoError) Error() string {
+	if e.Code == 0 {
+		return fmt.Sprintf("zoho people: %s", e.Message)
+	}
+	return fmt.Sprintf("zoho people: %s (code %d)", e.Message, e.Code)
+}
+
+// GRPCStatus lets status.FromError and the SDK retry logic pick up the matching gRPC code.
+func (e *ZohoError) GRPCStatus() *status.Status {
+	return status.New(e.GRPCCode(), e.Error())
+}
+
+// GRPCCode maps the Zoho error code, or the message for codes we do not know, to a gRPC code.
+func (e *ZohoError) GRPCCode() codes.Code {
+	switch e.Code {
+	case ErrCodeInvalidToken, ErrCodeAuthFailure:
+		return codes.Unauthenticated
+	case ErrCodeInvalidOAuthScope, ErrCodePermissionDenied:
+		return codes.PermissionDenied
+	case ErrCodeNoRecords:
+		return codes.NotFound
+	case ErrCodeInvalidFormName, ErrCodeInvalidViewName, ErrCodeInvalidFieldName, ErrCodeInvalidSearch:
+		return codes.InvalidArgument
+	}
+
+	message := strings.ToLower(e.Message)
+	switch {
+	case containsAny(message, "limit exceeded", "limit reached", "exceeded the", "too many", "blocked", "locked"):
+		return codes.ResourceExhausted
+	case containsAny(message, "scope", "permission", "not authorized", "access denied"):
+		return codes.PermissionDenied
+	case containsAny(message, "token", "authenticat"):
+		return codes.Unauthenticated
+	case containsAny(message, "not found", "does not exist", "no record"):
+		return codes.NotFound
+	case containsAny(message, "invalid"):
+		return codes.InvalidArgument
+	}
+
+	return codes.Unknown
+}
+
+// IsNoRecords reports whether err is Zoho's answer to a query that matched nothing.
+func IsNoRecords(err error) bool {
+	var zohoErr *ZohoError
+	return errors.As(err, &zohoErr) && zohoErr.Code == ErrCodeNoRecords
+}
+
+type errorEnvelope struct {
+	Response struct {
+		Message string          `json:"message"`
+		URI     string          `json:"uri"`
+		Status  int             `json:"status"`
+		Errors  json.RawMessage `json:"errors"`
+	} `json:"response"`
+}
+
+type errorDetail struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// withZohoErrorResponse returns a ZohoError when the response envelope reports a failure.
+func withZohoErrorResponse() uhttp.DoOption {
+	return func(resp *uhttp.WrapperResponse) error {
+		return parseZohoError(resp.Body)
+	}
+}
+
+func parseZohoError(body []byte) error {
+	var envelope errorEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		// Not an envelope, the response decoder reports malformed bodies.
+		return nil
+	}
+
+	if envelope.Response.Status != responseStatusFailure {
+		return nil
+	}
+
+	zohoErr := &ZohoError{
+		Message: envelope.Response.Message,
+		URI:     envelope.Response.URI,
+	}
+
+	// Zoho returns errors either as a single object or as a list of objects.
+	var details []errorDetail
+	var detail errorDetail
+	if err := json.Unmarshal(envelope.Response.Errors, &detail); err == nil {
+		details = append(details, detail)
+	} else if err := json.Unmarshal(envelope.Response.Errors, &details); err != nil {
+		details = nil
+	}
+
+	if len(details) != 0 {
+		zohoErr.Code = details[0].Code
+		if details[0].Message != "" {
+			zohoErr.Message = details[0].Message
+		}
+	}
+
+	return zohoErr
+}
+
+func containsAny(s string, substrings ...string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(s, substring) {
+			return true
+		}
+	}
+	return false
+}
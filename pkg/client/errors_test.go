@@ -0,0 +1,58 @@
+package client
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestParseZohoError(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+		expectedGRPC codes.Code
+	}{
+		{
+			name:         "invalid oauth scope",
+			body:         `{"response":{"message":"Error occurred","uri":"/api/forms/employee/getRecords","errors":{"code":7218,"message":"Invalid OAuthScope scope"},"status":1}}`,
+			expectedCode: ErrCodeInvalidOAuthScope,
+			expectedGRPC: codes.PermissionDenied,
+		},
+		{
+			name:         "no records as list",
+			body:         `{"response":{"message":"Error occurred","errors":[{"code":7024,"message":"No records found"}],"status":1}}`,
+			expectedCode: ErrCodeNoRecords,
+			expectedGRPC: codes.NotFound,
+		},
+		{
+			name:         "api limit by message",
+			body:         `{"response":{"message":"Error occurred","errors":{"code":7999,"message":"API call limit exceeded. Try again after 5 minutes"},"status":1}}`,
+			expectedCode: 7999,
+			expectedGRPC: codes.ResourceExhausted,
+		},
+		{
+			name:         "status without details",
+			body:         `{"response":{"message":"You do not have permission to access this form","status":1}}`,
+			expectedGRPC: codes.PermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parseZohoError([]byte(tt.body))
+			require.Error(t, err)
+
+			var zohoErr *ZohoError
+			require.True(t, errors.As(err, &zohoErr))
+			require.Equal(t, tt.expectedCode, zohoErr.Code)
+			require.Equal(t, tt.expectedGRPC, status.Code(errors.Join(err)))
+		})
+	}
+
+	require.NoError(t, parseZohoError([]byte(`{"response":{"result":[],"message":"Data fetched successfully","status":0}}`)))
+	require.NoError(t, parseZohoError([]byte(`[]`)))
+}
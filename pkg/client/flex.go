@@ -0,0 +1,204 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+// FlexInt64 is an integer field that Zoho sends as a number in some organizations, as a string
+// in others, and as "" when it is empty.
+type FlexInt64 int64
+
+func (i *FlexInt64) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	text := string(data)
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = strings.TrimSpace(unquoted)
+	}
+	if text == "" {
+		*i = 0
+		return nil
+	}
+
+	value, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		// Whole numbers are sometimes sent in exponent or decimal notation.
+		f, ferr := strconv.ParseFloat(text, 64)
+		if ferr != nil || f != float64(int64(f)) {
+			return fmt.Errorf("baton-zoho-people: invalid integer %s", data)
+		}
+		value = int64(f)
+	}
+
+	*i = FlexInt64(value)
+	return nil
+}
+
+func (i FlexInt64) String() string {
+	return strconv.FormatInt(int64(i), 10)
+}
+
+// normalizeRecord rewrites the scalar values of a record so they decode into the fields of the
+// given struct type: numbers and booleans sent for string fields become strings, and "" sent
+// for an object or a list is dropped. Fields that are not modelled are left alone.
+func normalizeRecord(data []byte, t reflect.Type) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	if !normalizeFields(fields, t) {
+		return data, nil
+	}
+
+	return json.Marshal(fields)
+}
+
+func normalizeFields(fields map[string]json.RawMessage, t reflect.Type) bool {
+	changed := false
+
+	for name, fieldType := range jsonFieldTypes(t) {
+		raw, ok := fields[name]
+		if !ok {
+			continue
+		}
+
+		if normalized, ok := normalizeValue(raw, fieldType); ok {
+			fields[name] = normalized
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+func normalizeValue(raw json.RawMessage, t reflect.Type) (json.RawMessage, bool) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, false
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		if raw[0] == '"' {
+			return nil, false
+		}
+		if raw[0] == '{' || raw[0] == '[' {
+			return json.RawMessage(`""`), true
+		}
+		// Numbers and booleans keep their literal text.
+		quoted, err := json.Marshal(string(raw))
+		if err != nil {
+			return nil, false
+		}
+		return quoted, true
+
+	case reflect.Struct:
+		if raw[0] != '{' {
+			return json.RawMessage("null"), true
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil || !normalizeFields(fields, t) {
+			return nil, false
+		}
+		normalized, err := json.Marshal(fields)
+		if err != nil {
+			return nil, false
+		}
+		return normalized, true
+
+	case reflect.Slice:
+		if raw[0] != '[' {
+			return json.RawMessage("null"), true
+		}
+		if t.Elem().Kind() != reflect.Struct {
+			return nil, false
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, false
+		}
+		changed := false
+		for index, item := range items {
+			if normalized, ok := normalizeValue(item, t.Elem()); ok {
+				items[index] = normalized
+				changed = true
+			}
+		}
+		if !changed {
+			return nil, false
+		}
+		normalized, err := json.Marshal(items)
+		if err != nil {
+			return nil, false
+		}
+		return normalized, true
+	}
+
+	return nil, false
+}
+
+var jsonFieldTypesCache sync.Map
+
+// jsonFieldTypes returns the types of the fields of a struct, keyed by their JSON name.
+func jsonFieldTypes(t reflect.Type) map[string]reflect.Type {
+	if cached, ok := jsonFieldTypesCache.Load(t); ok {
+		return cached.(map[string]reflect.Type)
+	}
+
+	types := make(map[string]reflect.Type, t.NumField())
+	for index := 0; index < t.NumField(); index++ {
+		field := t.Field(index)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		types[name] = field.Type
+	}
+
+	jsonFieldTypesCache.Store(t, types)
+	return types
+}
+
+// skipRecord logs records that cannot be decoded, the rest of the sync goes on without them.
+func skipRecord(ctx context.Context, form string) func(string, error) {
+	return func(recordID string, err error) {
+		ctxzap.Extract(ctx).Warn("skipping Zoho record that cannot be decoded",
+			zap.String("form", form), zap.String("record_id", recordID), zap.Error(err))
+	}
+}
+
+// decodeRecords decodes the records of a getRecords result one by one. Records that cannot be
+// decoded are handed to skip instead of failing the whole page.
+func decodeRecords[T any](result []map[string][]json.RawMessage, skip func(recordID string, err error)) []T {
+	var records []T
+	for _, item := range result {
+		for recordID, rawRecords := range item {
+			for _, raw := range rawRecords {
+				var record T
+				if err := json.Unmarshal(raw, &record); err != nil {
+					skip(recordID, err)
+					continue
+				}
+				records = append(records, record)
+			}
+		}
+	}
+	return records
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/conductorone/baton-zoho-people/test/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestFlexInt64(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected FlexInt64
+		wantErr  bool
+	}{
+		{value: `858578000000277092`, expected: 858578000000277092},
+		{value: `"858578000000277092"`, expected: 858578000000277092},
+		{value: `""`, expected: 0},
+		{value: `null`, expected: 0},
+		{value: `1.0`, expected: 1},
+		{value: `"1e3"`, expected: 1000},
+		{value: `"active"`, wantErr: true},
+		{value: `1.5`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			var actual FlexInt64
+			err := json.Unmarshal([]byte(tt.value), &actual)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestEmployee_UnmarshalJSON_MixedTypes(t *testing.T) {
+	var employee Employee
+	err := json.Unmarshal([]byte(`{
+		"Zoho_ID": "858578000000277092",
+		"EmployeeID": 20,
+		"Extension": 4711,
+		"Employeestatus": "Active",
+		"Employeestatus.type": "",
+		"Reporting_To": {"name": "Emma"},
+		"tabularSections": "",
+		"Cost_Center": 42
+	}`), &employee)
+	require.NoError(t, err)
+
+	require.Equal(t, FlexInt64(858578000000277092), employee.ZohoID)
+	require.Equal(t, "20", employee.EmployeeID)
+	require.Equal(t, "4711", employee.Extension)
+	require.Equal(t, FlexInt64(0), employee.EmployeeStatusType)
+	require.Empty(t, employee.ReportingTo)
+	require.JSONEq(t, `{"name": "Emma"}`, string(employee.Fields["Reporting_To"]))
+	require.JSONEq(t, `42`, string(employee.Fields["Cost_Center"]))
+
+	var department Department
+	err = json.Unmarshal([]byte(`{"Zoho_ID": "858578000000277093", "Department": "Sales", "Mail_Alias": 7}`), &department)
+	require.NoError(t, err)
+	require.Equal(t, FlexInt64(858578000000277093), department.ZohoID)
+	require.Equal(t, "7", department.MailAlias)
+}
+
+// Tests that a record that cannot be decoded is skipped without failing the rest of the page.
+func TestZohoPeopleClient_ListUsersSkipsMalformedRecords(t *testing.T) {
+	body := `{"response":{"result":[
+		{"1":[{"Zoho_ID":1,"FirstName":"Christopher"}]},
+		{"2":[{"Zoho_ID":"not a number","FirstName":"David"}]},
+		{"3":[{"Zoho_ID":3,"FirstName":"Emma","Employeestatus.type":"1"}]}
+	],"status":0}}`
+	c := NewClient(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"}),
+		mock.HTTPClient(func(*http.Request) (*http.Response, error) {
+			return mock.JSONResponse(body), nil
+		}))
+
+	employees, _, _, err := c.ListUsers(context.Background(), PageOptions{PageSize: 10})
+	require.NoError(t, err)
+
+	var names []string
+	for _, employee := range employees {
+		names = append(names, employee.FirstName)
+	}
+	require.ElementsMatch(t, []string{"Christopher", "Emma"}, names)
+}
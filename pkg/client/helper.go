@@ -1,23 +1,20 @@
 package client
 
 import (
-	"context"
-	"fmt"
 	"net/url"
-	"strconv"
-
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/clientcredentials"
+	"strings"
 )
 
-// By default, the number of objects returned per page is 100. The maximum number of object supported per page.
-// It can be adjusted by adding the 'per_page' parameter in the query string.
-
-const ItemsPerPage = 100
+// ItemsPerPage is the largest page getRecords returns, it is also used when no page size is given.
+const ItemsPerPage = 200
 
+// PageOptions selects a page of getRecords. PageToken is the sIndex of the first record on the
+// page, empty for the first page, and PageSize the limit.
 type PageOptions struct {
 	PageSize  int    `url:"limit,omitempty"`
 	PageToken string `url:"sIndex,omitempty"`
+	// Search limits the records to those matching all the criteria.
+	Search []SearchParam `url:"-"`
 }
 
 var (
@@ -30,41 +27,33 @@ var (
 	}
 )
 
-func getPageSize(pageSize int) int {
-	if pageSize <= 0 || pageSize > ItemsPerPage {
-		pageSize = ItemsPerPage
+// domainFromAPIDomain maps the api_domain of a token response, e.g. https://www.zohoapis.eu, to its data center domain.
+func domainFromAPIDomain(apiDomain string) (string, bool) {
+	if apiDomain == "" {
+		return "", false
 	}
-	return pageSize
-}
 
-func getNextPageToken(prevPageToken string, pageSize, recordsCount int) string {
-	if prevPageToken == "" {
-		return "1"
+	u, err := url.Parse(apiDomain)
+	if err != nil {
+		return "", false
 	}
 
-	prevToken, _ := strconv.Atoi(prevPageToken)
-	pageSize = getPageSize(pageSize)
+	_, domain, found := strings.Cut(u.Hostname(), "zohoapis.")
+	if !found {
+		return "", false
+	}
 
-	if recordsCount < pageSize {
-		return ""
+	for _, d := range TokenURL {
+		if d == domain {
+			return domain, true
+		}
 	}
 
-	return strconv.Itoa(prevToken + pageSize)
+	return "", false
 }
 
 type ReqOpt func(reqURL *url.URL)
 
-func WithPageLimit(pageSize int) ReqOpt {
-	return WithQueryParam("limit", strconv.Itoa(getPageSize(pageSize)))
-}
-
-func WithPageIndex(nextPageToken string) ReqOpt {
-	if nextPageToken == "" {
-		nextPageToken = "1"
-	}
-	return WithQueryParam("sIndex", nextPageToken)
-}
-
 func WithQueryParam(key string, value string) ReqOpt {
 	return func(reqURL *url.URL) {
 		q := reqURL.Query()
@@ -72,26 +61,3 @@ func WithQueryParam(key string, value string) ReqOpt {
 		reqURL.RawQuery = q.Encode()
 	}
 }
-
-func getTokenSource(
-	ctx context.Context,
-	clientId,
-	clientSecret,
-	clientCode,
-	domainAccount string,
-) oauth2.TokenSource {
-	cfg := clientcredentials.Config{
-		EndpointParams: url.Values{
-			"client_id":     []string{clientId},
-			"client_secret": []string{clientSecret},
-			"grant_type":    []string{"authorization_code"},
-			"redirect_uri":  []string{"https://www.zoho.com"},
-			"code":          []string{clientCode},
-		},
-		AuthStyle:    oauth2.AuthStyleInHeader,
-		ClientID:     clientId,
-		ClientSecret: clientSecret,
-		TokenURL:     fmt.Sprintf(accessTokenUrl, TokenURL[domainAccount]),
-	}
-	return cfg.TokenSource(ctx)
-}
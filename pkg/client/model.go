@@ -1,5 +1,10 @@
 package client
 
+import (
+	"encoding/json"
+	"reflect"
+)
+
 type TokenResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
@@ -8,12 +13,29 @@ type TokenResponse struct {
 	TokenType    string `json:"token_type"`
 	ExpiresIn    int    `json:"expires_in"`
 }
-type EmployeeResponse struct {
+
+// recordsResponse is a getRecords response with the records left undecoded, so a malformed record
+// can be skipped without losing the rest of the page.
+type recordsResponse struct {
+	Response struct {
+		Result  []map[string][]json.RawMessage `json:"result"`
+		Message string                         `json:"message"`
+		URI     string                         `json:"uri"`
+		Status  int                            `json:"status"`
+	} `json:"response"`
+}
+
+// RecordResponse is the response of the insertRecord and updateRecord form APIs.
+type RecordResponse struct {
 	Response struct {
-		Result  []map[string][]Employee `json:"result"`
-		Message string                  `json:"message"`
-		URI     string                  `json:"uri"`
-		Status  int                     `json:"status"`
+		Result struct {
+			// PkID is the ID of the inserted or updated record, sent either as a string or a number.
+			PkID    json.RawMessage `json:"pkId"`
+			Message string          `json:"message"`
+		} `json:"result"`
+		Message string `json:"message"`
+		URI     string `json:"uri"`
+		Status  int    `json:"status"`
 	} `json:"response"`
 }
 
@@ -25,16 +47,18 @@ type SingleEmployeeResponse struct {
 		Status  int        `json:"status"`
 	} `json:"response"`
 }
+
+// Employee holds the employee form fields the connector uses. Sensitive fields such as the
+// social security number, date of birth or home address are deliberately not modelled. Employee
+// records are reduced to the modelled fields before they are decoded, see WithProfileFields to keep
+// further fields.
 type Employee struct {
 	MiddleName      string `json:"Middle_Name"`
 	EmailID         string `json:"EmailID"`
 	CreatedTime     string `json:"CreatedTime"`
 	EmployeeTypeId  string `json:"Employee_type.id"`
-	DateOfBirth     string `json:"Date_of_birth"`
 	AddedTime       string `json:"AddedTime"`
 	Photo           string `json:"Photo"`
-	MaritalStatus   string `json:"Marital_status"`
-	Gender          string `json:"Gender"`
 	ModifiedBy      string `json:"ModifiedBy"`
 	ApprovalStatus  string `json:"ApprovalStatus"`
 	Department      string `json:"Department"`
@@ -57,76 +81,64 @@ type Employee struct {
 			TabularROWID    string `json:"tabular.ROWID"`
 			RELEVANCEId     string `json:"RELEVANCE.id"`
 		} `json:"Work Experience"`
-		DependentDetails []struct {
-		} `json:"Dependent Details"`
 	} `json:"tabularSections"`
-	AddedBy                     string `json:"AddedBy"`
-	MobileCountryCode           string `json:"Mobile.country_code"`
-	Tags                        string `json:"Tags"`
-	ReportingTo                 string `json:"Reporting_To"`
-	PhotoDownloadUrl            string `json:"Photo_downloadUrl"`
-	SourceOfHireId              string `json:"Source_of_hire.id"`
-	TotalExperienceDisplayValue string `json:"total_experience.displayValue"`
-	Citizenship                 string `json:"Citizenship"`
-	EmployeeStatus              string `json:"Employeestatus"`
-	Role                        string `json:"Role"`
-	Experience                  string `json:"Experience"`
-	EmployeeType                string `json:"Employee_type"`
-	AddedByID                   string `json:"AddedBy.ID"`
-	SocialSecurityNumber        string `json:"Social_Security_Number"`
-	RoleID                      string `json:"Role.ID"`
-	LastName                    string `json:"LastName"`
-	EmployeeID                  string `json:"EmployeeID"`
-	ZUID                        string `json:"ZUID"`
-	VeteranStatus               string `json:"Veteran_Status"`
-	DateOfExit                  string `json:"Dateofexit"`
-	PermanentAddress            string `json:"Permanent_Address"`
-	OtherEmail                  string `json:"Other_Email"`
-	LocationName                string `json:"LocationName"`
-	WorkLocation                string `json:"Work_location"`
-	PresentAddress              string `json:"Present_Address"`
-	NickName                    string `json:"Nick_Name"`
-	TotalExperience             string `json:"total_experience"`
-	ModifiedTime                string `json:"ModifiedTime"`
-	ReportingToMailID           string `json:"Reporting_To.MailID"`
-	ZohoID                      int64  `json:"Zoho_ID"`
-	DesignationID               string `json:"Designation.ID"`
-	SourceOfHire                string `json:"Source_of_hire"`
-	Designation                 string `json:"Designation"`
-	EthnicityId                 string `json:"Ethnicity.id"`
-	MaritalStatusId             string `json:"Marital_status.id"`
-	FirstName                   string `json:"FirstName"`
-	AboutMe                     string `json:"AboutMe"`
-	DateOfJoining               string `json:"Dateofjoining"`
-	ExperienceDisplayValue      string `json:"Experience.displayValue"`
-	Mobile                      string `json:"Mobile"`
-	Extension                   string `json:"Extension"`
-	ModifiedByID                string `json:"ModifiedBy.ID"`
-	Ethnicity                   string `json:"Ethnicity"`
-	ReportingToID               string `json:"Reporting_To.ID"`
-	WorkPhone                   string `json:"Work_phone"`
-	EmployeeStatusType          int    `json:"Employeestatus.type"`
-	DepartmentID                string `json:"Department.ID"`
-	PresentAddressChildValues   struct {
-		CITY        string `json:"CITY"`
-		COUNTRY     string `json:"COUNTRY"`
-		STATE       string `json:"STATE"`
-		ADDRESS1    string `json:"ADDRESS1"`
-		PINCODE     string `json:"PINCODE"`
-		ADDRESS2    string `json:"ADDRESS2"`
-		STATECODE   string `json:"STATE_CODE"`
-		COUNTRYCODE string `json:"COUNTRY_CODE"`
-	} `json:"Present_Address.childValues"`
-	Expertise string `json:"Expertise"`
+	AddedBy                     string    `json:"AddedBy"`
+	Tags                        string    `json:"Tags"`
+	ReportingTo                 string    `json:"Reporting_To"`
+	PhotoDownloadUrl            string    `json:"Photo_downloadUrl"`
+	SourceOfHireId              string    `json:"Source_of_hire.id"`
+	TotalExperienceDisplayValue string    `json:"total_experience.displayValue"`
+	EmployeeStatus              string    `json:"Employeestatus"`
+	Role                        string    `json:"Role"`
+	Experience                  string    `json:"Experience"`
+	EmployeeType                string    `json:"Employee_type"`
+	AddedByID                   string    `json:"AddedBy.ID"`
+	RoleID                      string    `json:"Role.ID"`
+	LastName                    string    `json:"LastName"`
+	EmployeeID                  string    `json:"EmployeeID"`
+	ZUID                        string    `json:"ZUID"`
+	DateOfExit                  string    `json:"Dateofexit"`
+	OtherEmail                  string    `json:"Other_Email"`
+	LocationName                string    `json:"LocationName"`
+	WorkLocation                string    `json:"Work_location"`
+	NickName                    string    `json:"Nick_Name"`
+	TotalExperience             string    `json:"total_experience"`
+	ModifiedTime                string    `json:"ModifiedTime"`
+	ReportingToMailID           string    `json:"Reporting_To.MailID"`
+	ZohoID                      FlexInt64 `json:"Zoho_ID"`
+	DesignationID               string    `json:"Designation.ID"`
+	SourceOfHire                string    `json:"Source_of_hire"`
+	Designation                 string    `json:"Designation"`
+	FirstName                   string    `json:"FirstName"`
+	AboutMe                     string    `json:"AboutMe"`
+	DateOfJoining               string    `json:"Dateofjoining"`
+	ExperienceDisplayValue      string    `json:"Experience.displayValue"`
+	Extension                   string    `json:"Extension"`
+	ModifiedByID                string    `json:"ModifiedBy.ID"`
+	ReportingToID               string    `json:"Reporting_To.ID"`
+	WorkPhone                   string    `json:"Work_phone"`
+	EmployeeStatusType          FlexInt64 `json:"Employeestatus.type"`
+	DepartmentID                string    `json:"Department.ID"`
+	Expertise                   string    `json:"Expertise"`
+
+	// Fields keeps the raw value of every field left in the record, the modelled fields and those
+	// kept with WithProfileFields, keyed by the Zoho field label.
+	Fields map[string]json.RawMessage `json:"-"`
 }
 
-type DepartmentResponse struct {
-	Response struct {
-		Result  []map[string][]Department `json:"result"`
-		Message string                    `json:"message"`
-		URI     string                    `json:"uri"`
-		Status  int                       `json:"status"`
-	} `json:"response"`
+// UnmarshalJSON decodes the modelled fields, tolerating the scalar types Zoho mixes up, and keeps
+// the raw value of every field of the record in Fields.
+func (e *Employee) UnmarshalJSON(data []byte) error {
+	type employee Employee
+	normalized, err := normalizeRecord(data, reflect.TypeOf(employee{}))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(normalized, (*employee)(e)); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, &e.Fields)
 }
 
 type SingleDepartmentResponse struct {
@@ -139,20 +151,90 @@ type SingleDepartmentResponse struct {
 }
 
 type Department struct {
-	CreatedTime        string `json:"CreatedTime"`
-	DepartmentLeadMail string `json:"Department_Lead.MailID"`
-	AddedTime          string `json:"AddedTime"`
-	DepartmentLead     string `json:"Department_Lead"`
-	ModifiedBy         string `json:"ModifiedBy"`
-	ApprovalStatus     string `json:"ApprovalStatus"`
-	ModifiedByID       string `json:"ModifiedBy.ID"`
-	Department         string `json:"Department"`
-	DepartmentLeadID   string `json:"Department_Lead.ID"`
-	ParentDepartmentID string `json:"Parent_Department.ID"`
-	ModifiedTime       string `json:"ModifiedTime"`
-	ZohoID             int    `json:"Zoho_ID"`
-	AddedByID          string `json:"AddedBy.ID"`
-	ParentDepartment   string `json:"Parent_Department"`
-	AddedBy            string `json:"AddedBy"`
-	MailAlias          string `json:"Mail_Alias"`
+	CreatedTime        string    `json:"CreatedTime"`
+	DepartmentLeadMail string    `json:"Department_Lead.MailID"`
+	AddedTime          string    `json:"AddedTime"`
+	DepartmentLead     string    `json:"Department_Lead"`
+	ModifiedBy         string    `json:"ModifiedBy"`
+	ApprovalStatus     string    `json:"ApprovalStatus"`
+	ModifiedByID       string    `json:"ModifiedBy.ID"`
+	Department         string    `json:"Department"`
+	DepartmentLeadID   string    `json:"Department_Lead.ID"`
+	ParentDepartmentID string    `json:"Parent_Department.ID"`
+	ModifiedTime       string    `json:"ModifiedTime"`
+	ZohoID             FlexInt64 `json:"Zoho_ID"`
+	AddedByID          string    `json:"AddedBy.ID"`
+	ParentDepartment   string    `json:"Parent_Department"`
+	AddedBy            string    `json:"AddedBy"`
+	MailAlias          string    `json:"Mail_Alias"`
+}
+
+// UnmarshalJSON decodes the department, tolerating the scalar types Zoho mixes up.
+func (d *Department) UnmarshalJSON(data []byte) error {
+	type department Department
+	normalized, err := normalizeRecord(data, reflect.TypeOf(department{}))
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(normalized, (*department)(d))
+}
+
+type FormsResponse struct {
+	Response struct {
+		Result  []Form `json:"result"`
+		Message string `json:"message"`
+		URI     string `json:"uri"`
+		Status  int    `json:"status"`
+	} `json:"response"`
+}
+
+// Form is a form of the organization, e.g. the Employee or Department form.
+type Form struct {
+	DisplayName  string `json:"displayName"`
+	FormLinkName string `json:"formLinkName"`
+	IsCustom     bool   `json:"iscustom"`
+	IsVisible    bool   `json:"isVisible"`
+}
+
+type FormComponentsResponse struct {
+	Response struct {
+		Result  []FormComponent `json:"result"`
+		Message string          `json:"message"`
+		URI     string          `json:"uri"`
+		Status  int             `json:"status"`
+	} `json:"response"`
+}
+
+// FormComponent describes a field of a form. LabelName is the key of the field in the records
+// and the name used in search criteria, DisplayName is the name shown in Zoho People.
+type FormComponent struct {
+	ComponentType string `json:"comptype"`
+	DisplayName   string `json:"displayname"`
+	LabelName     string `json:"labelname"`
+	IsMandatory   bool   `json:"ismandatory"`
+
+	// Fields keeps every attribute of the component, including the ones that are not modelled.
+	Fields map[string]json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON decodes the modelled attributes and keeps the raw value of every attribute in Fields.
+func (f *FormComponent) UnmarshalJSON(data []byte) error {
+	type formComponent FormComponent
+	if err := json.Unmarshal(data, (*formComponent)(f)); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, &f.Fields)
+}
+
+// LookupForm returns the link name of the form a lookup field points to, when Zoho includes it.
+func (f *FormComponent) LookupForm() string {
+	for _, key := range []string{"lookupFormLinkName", "formLinkName"} {
+		var value string
+		if err := json.Unmarshal(f.Fields[key], &value); err == nil && value != "" {
+			return value
+		}
+	}
+	return ""
 }
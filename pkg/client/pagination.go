@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"iter"
+	"strconv"
+
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func getPageSize(pageSize int) int {
+	if pageSize <= 0 || pageSize > ItemsPerPage {
+		pageSize = ItemsPerPage
+	}
+	return pageSize
+}
+
+// pageCursor is the position of a getRecords page. Zoho numbers the records of a listing from 1,
+// sIndex is the number of the first record on the page and limit the number of records.
+type pageCursor struct {
+	index int
+	size  int
+}
+
+func newPageCursor(options PageOptions) (pageCursor, error) {
+	cursor := pageCursor{index: 1, size: getPageSize(options.PageSize)}
+	if options.PageToken == "" {
+		return cursor, nil
+	}
+
+	index, err := strconv.Atoi(options.PageToken)
+	if err != nil || index < 1 {
+		return pageCursor{}, status.Errorf(codes.InvalidArgument, "baton-zoho-people: invalid page token %q", options.PageToken)
+	}
+	cursor.index = index
+
+	return cursor, nil
+}
+
+func (p pageCursor) reqOptions() []ReqOpt {
+	return []ReqOpt{
+		WithQueryParam("sIndex", strconv.Itoa(p.index)),
+		WithQueryParam("limit", strconv.Itoa(p.size)),
+	}
+}
+
+// next returns the token of the page after one that held count records. A short page is the last one.
+func (p pageCursor) next(count int) string {
+	if count < p.size {
+		return ""
+	}
+	return strconv.Itoa(p.index + count)
+}
+
+// countRecords returns the number of records of a getRecords result, including the ones that cannot be decoded.
+func countRecords(result []map[string][]json.RawMessage) int {
+	count := 0
+	for _, item := range result {
+		for _, records := range item {
+			count += len(records)
+		}
+	}
+	return count
+}
+
+// ListFunc lists a page of records, such as ZohoPeopleClient.ListUsers.
+type ListFunc[T any] func(ctx context.Context, options PageOptions) ([]T, string, annotations.Annotations, error)
+
+// Records iterates over every record of a listing, starting at the page selected by options and
+// following the page tokens until the last page. The iteration stops at the first error.
+func Records[T any](ctx context.Context, list ListFunc[T], options PageOptions) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for {
+			records, nextPageToken, _, err := list(ctx, options)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+
+			for _, record := range records {
+				if !yield(record, nil) {
+					return
+				}
+			}
+
+			if nextPageToken == "" {
+				return
+			}
+			if !advances(options.PageToken, nextPageToken) {
+				var zero T
+				yield(zero, status.Errorf(codes.Internal, "baton-zoho-people: page token %q does not advance past %q", nextPageToken, options.PageToken))
+				return
+			}
+			options.PageToken = nextPageToken
+		}
+	}
+}
+
+// advances reports whether the next page token moves past the current one, so a listing cannot loop forever.
+func advances(pageToken, nextPageToken string) bool {
+	next, err := strconv.Atoi(nextPageToken)
+	if err != nil {
+		return nextPageToken != pageToken
+	}
+
+	current := 1
+	if pageToken != "" {
+		if current, err = strconv.Atoi(pageToken); err != nil {
+			return nextPageToken != pageToken
+		}
+	}
+
+	return next > current
+}
@@ -0,0 +1,141 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-zoho-people/test/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// records serves a getRecords listing of total employees the way Zoho pages it: sIndex is the
+// 1-based number of the first record and limit the page size, capped at 200. With emptyPastEnd a
+// page past the last record is answered with an empty result instead of error 7024. The requested
+// pages are recorded as sIndex/limit.
+func records(total int, emptyPastEnd bool, requests *[]string) *ZohoPeopleClient {
+	return NewClient(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"}),
+		mock.HTTPClient(func(req *http.Request) (*http.Response, error) {
+			q := req.URL.Query()
+			*requests = append(*requests, fmt.Sprintf("%s/%s", q.Get("sIndex"), q.Get("limit")))
+
+			sIndex, _ := strconv.Atoi(q.Get("sIndex"))
+			limit, _ := strconv.Atoi(q.Get("limit"))
+			limit = min(limit, 200)
+
+			var result []string
+			for id := sIndex; id < sIndex+limit && id <= total; id++ {
+				result = append(result, fmt.Sprintf(`{"%d":[{"Zoho_ID":%d}]}`, id, id))
+			}
+
+			body := fmt.Sprintf(`{"response":{"result":[%s],"status":0}}`, strings.Join(result, ","))
+			if len(result) == 0 && !emptyPastEnd {
+				body = `{"response":{"errors":{"code":7024,"message":"No records found"},"status":1}}`
+			}
+			return mock.JSONResponse(body), nil
+		}))
+}
+
+func TestRecords(t *testing.T) {
+	tests := []struct {
+		total        int
+		pageSize     int
+		emptyPastEnd bool
+		requests     []string
+	}{
+		{total: 0, pageSize: 0, requests: []string{"1/200"}},
+		{total: 1, pageSize: 0, requests: []string{"1/200"}},
+		{total: 199, pageSize: 0, requests: []string{"1/200"}},
+		{total: 200, pageSize: 0, requests: []string{"1/200", "201/200"}},
+		{total: 200, pageSize: 0, emptyPastEnd: true, requests: []string{"1/200", "201/200"}},
+		{total: 201, pageSize: 0, requests: []string{"1/200", "201/200"}},
+		{total: 450, pageSize: 500, requests: []string{"1/200", "201/200", "401/200"}},
+		{total: 5, pageSize: 2, requests: []string{"1/2", "3/2", "5/2"}},
+		{total: 4, pageSize: 2, requests: []string{"1/2", "3/2", "5/2"}},
+		{total: 3, pageSize: 1, requests: []string{"1/1", "2/1", "3/1", "4/1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d records, page size %d, empty past end %t", tt.total, tt.pageSize, tt.emptyPastEnd), func(t *testing.T) {
+			var requests []string
+			c := records(tt.total, tt.emptyPastEnd, &requests)
+
+			var ids []int64
+			for employee, err := range Records(context.Background(), c.ListUsers, PageOptions{PageSize: tt.pageSize}) {
+				require.NoError(t, err)
+				ids = append(ids, int64(employee.ZohoID))
+			}
+
+			// Every record exactly once and in order.
+			require.Len(t, ids, tt.total)
+			for index, id := range ids {
+				require.Equal(t, int64(index+1), id)
+			}
+			require.Equal(t, tt.requests, requests)
+		})
+	}
+}
+
+func TestZohoPeopleClient_ListUsersPageTokens(t *testing.T) {
+	ctx := context.Background()
+
+	var requests []string
+	c := records(3, false, &requests)
+
+	employees, nextPageToken, _, err := c.ListUsers(ctx, PageOptions{PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, employees, 2)
+	require.Equal(t, "3", nextPageToken)
+
+	employees, nextPageToken, _, err = c.ListUsers(ctx, PageOptions{PageSize: 2, PageToken: nextPageToken})
+	require.NoError(t, err)
+	require.Len(t, employees, 1)
+	require.Equal(t, FlexInt64(3), employees[0].ZohoID)
+	require.Empty(t, nextPageToken)
+
+	for _, token := range []string{"0", "-1", "abc"} {
+		_, _, _, err = c.ListUsers(ctx, PageOptions{PageToken: token})
+		require.Equal(t, codes.InvalidArgument, status.Code(err), token)
+	}
+}
+
+// Tests that records that cannot be decoded still count towards the position of the next page.
+func TestZohoPeopleClient_ListUsersCountsSkippedRecords(t *testing.T) {
+	body := `{"response":{"result":[
+		{"1":[{"Zoho_ID":1}]},
+		{"2":[{"Zoho_ID":"not a number"}]}
+	],"status":0}}`
+	c := NewClient(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"}),
+		mock.HTTPClient(func(*http.Request) (*http.Response, error) {
+			return mock.JSONResponse(body), nil
+		}))
+
+	employees, nextPageToken, _, err := c.ListUsers(context.Background(), PageOptions{PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, employees, 1)
+	require.Equal(t, "3", nextPageToken)
+}
+
+func TestRecords_StopsOnTokensThatDoNotAdvance(t *testing.T) {
+	calls := 0
+	list := func(_ context.Context, options PageOptions) ([]int, string, annotations.Annotations, error) {
+		calls++
+		return []int{calls}, "1", nil, nil
+	}
+
+	var err error
+	for _, err = range Records(context.Background(), list, PageOptions{PageToken: "1"}) {
+		if err != nil {
+			break
+		}
+	}
+	require.Equal(t, codes.Internal, status.Code(err))
+	require.Equal(t, 1, calls)
+}
@@ -0,0 +1,349 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// Zoho locks the API for a few minutes when a per-minute threshold is exceeded and
+	// does not always say for how long.
+	defaultLockOut = 5 * time.Minute
+	// Retries inside the client only wait for short windows, longer lock-outs are handed
+	// back to the syncer together with the reset time.
+	maxRetryWait  = time.Minute
+	maxRetries    = 3
+	baseRetryWait = time.Second
+)
+
+var lockOutPattern = regexp.MustCompile(`(?i)(\d+)\s*(seconds?|secs?|minutes?|mins?|hours?|hrs?)`)
+
+// budgetFileSuffix names the file the daily call count is kept in, next to the token cache.
+const budgetFileSuffix = ".budget"
+
+// budgetSaveDelay is how long the daily call count may go unsaved. The calls made in that window
+// are written together instead of rewriting the budget file on every call.
+const budgetSaveDelay = 5 * time.Second
+
+// rateLimiter tracks Zoho People lock-out windows and an optional daily call budget.
+// Zoho signals throttling with error codes inside the response rather than X-RateLimit-* headers.
+type rateLimiter struct {
+	mu          sync.Mutex
+	dailyBudget int64
+	dailyCalls  int64
+	day         time.Time
+	lockedUntil time.Time
+	// store keeps the calls made today across runs. Without it every run starts with the full budget.
+	store       *encryptedFile
+	storeLoaded bool
+	// saveScheduled is set while a save of the call count is pending.
+	saveScheduled bool
+	// saveMu orders the writes of the budget file.
+	saveMu sync.Mutex
+
+	now       func() time.Time
+	sleep     func(ctx context.Context, d time.Duration) error
+	afterFunc func(d time.Duration, f func())
+}
+
+func newRateLimiter(dailyBudget int64) *rateLimiter {
+	return &rateLimiter{
+		dailyBudget: dailyBudget,
+		now:         time.Now,
+		sleep:       sleepContext,
+		afterFunc: func(d time.Duration, f func()) {
+			time.AfterFunc(d, f)
+		},
+	}
+}
+
+// WithDailyCallBudget caps the number of API calls the client makes per UTC day. Zero means no cap.
+func WithDailyCallBudget(budget int64) Option {
+	return func(client *ZohoPeopleClient) {
+		client.limiter.mu.Lock()
+		defer client.limiter.mu.Unlock()
+		client.limiter.dailyBudget = budget
+	}
+}
+
+// budgetState is the daily call count kept in the budget file.
+type budgetState struct {
+	Day   time.Time `json:"day"`
+	Calls int64     `json:"calls"`
+}
+
+// acquire takes one call from the daily budget. It fails while Zoho has locked the API
+// or when the budget for the day is spent.
+func (r *rateLimiter) acquire(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if now.Before(r.lockedUntil) {
+		return r.overLimitError(codes.Unavailable, "zoho people API is locked", r.lockedUntil)
+	}
+
+	persist := r.dailyBudget > 0 && r.store != nil
+	if persist && !r.storeLoaded {
+		if err := r.loadBudget(); err != nil {
+			ctxzap.Extract(ctx).Warn("ignoring unreadable Zoho API budget file", zap.String("path", r.store.path), zap.Error(err))
+		}
+		r.storeLoaded = true
+	}
+
+	r.rollDay(now)
+	if r.dailyBudget > 0 && r.dailyCalls >= r.dailyBudget {
+		return r.overLimitError(codes.ResourceExhausted, "daily zoho people API call budget exhausted", r.day.AddDate(0, 0, 1))
+	}
+
+	r.dailyCalls++
+
+	if persist && !r.saveScheduled {
+		r.saveScheduled = true
+		l := ctxzap.Extract(ctx)
+		r.afterFunc(budgetSaveDelay, func() {
+			if err := r.saveBudget(); err != nil {
+				l.Warn("error writing Zoho API budget file", zap.String("path", r.store.path), zap.Error(err))
+			}
+		})
+	}
+	return nil
+}
+
+func (r *rateLimiter) loadBudget() error {
+	data, err := r.store.read()
+	if err != nil || data == nil {
+		return err
+	}
+
+	var state budgetState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+
+	r.day = state.Day
+	r.dailyCalls = state.Calls
+	return nil
+}
+
+// saveBudget writes the current call count. The file is written outside of mu, so API calls do
+// not wait for the disk.
+func (r *rateLimiter) saveBudget() error {
+	r.saveMu.Lock()
+	defer r.saveMu.Unlock()
+
+	r.mu.Lock()
+	state := budgetState{Day: r.day, Calls: r.dailyCalls}
+	r.saveScheduled = false
+	r.mu.Unlock()
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	return r.store.write(data)
+}
+
+// lockOut records a lock-out window reported by Zoho.
+func (r *rateLimiter) lockOut(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	until := r.now().Add(d)
+	if until.After(r.lockedUntil) {
+		r.lockedUntil = until
+	}
+}
+
+// retryWait returns how long to wait before the given retry attempt.
+func (r *rateLimiter) retryWait(attempt int) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if wait := r.lockedUntil.Sub(r.now()); wait > 0 {
+		return wait
+	}
+
+	wait := baseRetryWait << attempt
+	// #nosec G404 -- jitter does not need a cryptographic source.
+	return wait + time.Duration(rand.Int63n(int64(wait)/2+1))
+}
+
+// description returns the rate limit annotation for a successful call.
+func (r *rateLimiter) description() *v2.RateLimitDescription {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.dailyBudget <= 0 {
+		return nil
+	}
+
+	return &v2.RateLimitDescription{
+		Status:    v2.RateLimitDescription_STATUS_OK,
+		Limit:     r.dailyBudget,
+		Remaining: max(r.dailyBudget-r.dailyCalls, 0),
+		ResetAt:   timestamppb.New(r.day.AddDate(0, 0, 1)),
+	}
+}
+
+// lockOutError converts a throttling error into an Unavailable status carrying the reset time,
+// which makes the syncer wait for the lock-out window before retrying.
+func (r *rateLimiter) lockOutError(err error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	resetAt := r.lockedUntil
+	if resetAt.IsZero() || resetAt.Before(r.now()) {
+		resetAt = r.now().Add(baseRetryWait)
+	}
+
+	return errors.Join(r.overLimitError(codes.Unavailable, "zoho people API rate limit exceeded", resetAt), err)
+}
+
+func (r *rateLimiter) overLimitError(code codes.Code, message string, resetAt time.Time) error {
+	st := status.New(code, message)
+	// The syncer divides the time until ResetAt by Limit, a lock-out allows the next call only
+	// once the window is over.
+	st, err := st.WithDetails(&v2.RateLimitDescription{
+		Status:    v2.RateLimitDescription_STATUS_OVERLIMIT,
+		Limit:     1,
+		Remaining: 0,
+		ResetAt:   timestamppb.New(resetAt),
+	})
+	if err != nil {
+		return status.Error(code, message)
+	}
+	return st.Err()
+}
+
+func (r *rateLimiter) rollDay(now time.Time) {
+	day := now.UTC().Truncate(24 * time.Hour)
+	if !day.Equal(r.day) {
+		r.day = day
+		r.dailyCalls = 0
+	}
+}
+
+// isThrottled reports whether err is worth retrying after a wait.
+func isThrottled(err error) bool {
+	var zohoErr *ZohoError
+	if errors.As(err, &zohoErr) && zohoErr.GRPCCode() == codes.ResourceExhausted {
+		return true
+	}
+	return status.Code(err) == codes.Unavailable
+}
+
+// parseLockOut extracts the lock-out window from messages like "Try again after 5 minutes".
+func parseLockOut(message string) time.Duration {
+	match := lockOutPattern.FindStringSubmatch(message)
+	if match == nil {
+		return defaultLockOut
+	}
+
+	n, err := strconv.Atoi(match[1])
+	if err != nil || n <= 0 {
+		return defaultLockOut
+	}
+
+	unit := strings.ToLower(match[2])
+	switch {
+	case strings.HasPrefix(unit, "h"):
+		return time.Duration(n) * time.Hour
+	case strings.HasPrefix(unit, "m"):
+		return time.Duration(n) * time.Minute
+	default:
+		return time.Duration(n) * time.Second
+	}
+}
+
+// throttleTransport turns Zoho's throttling responses, which come back as HTTP 200, into
+// HTTP 429 so they are neither cached nor decoded as data, and records the lock-out window.
+type throttleTransport struct {
+	base    http.RoundTripper
+	limiter *rateLimiter
+}
+
+func (t *throttleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		lockOut := defaultLockOut
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			lockOut = time.Duration(seconds) * time.Second
+		}
+		t.limiter.lockOut(lockOut)
+		return resp, nil
+	}
+
+	if resp.StatusCode != http.StatusOK || resp.Body == nil || isBinaryContent(resp.Header) {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	var zohoErr *ZohoError
+	if !errors.As(parseZohoError(body), &zohoErr) || zohoErr.GRPCCode() != codes.ResourceExhausted {
+		return resp, nil
+	}
+
+	lockOut := parseLockOut(zohoErr.Message)
+	t.limiter.lockOut(lockOut)
+
+	resp.StatusCode = http.StatusTooManyRequests
+	resp.Status = fmt.Sprintf("%d %s", http.StatusTooManyRequests, http.StatusText(http.StatusTooManyRequests))
+	resp.Header = resp.Header.Clone()
+	resp.Header.Set("Retry-After", strconv.Itoa(int(lockOut.Seconds())))
+	return resp, nil
+}
+
+// isBinaryContent reports whether the response carries a file, such as an employee photo, rather
+// than an API document that could hold a throttling error.
+func isBinaryContent(header http.Header) bool {
+	contentType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	return strings.HasPrefix(contentType, "image/") || contentType == "application/octet-stream"
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
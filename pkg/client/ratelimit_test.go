@@ -0,0 +1,186 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+	"github.com/conductorone/baton-zoho-people/test/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	throttledBody = `{"response":{"message":"Error occurred","errors":{"code":7999,"message":"API call limit exceeded. Try again after 2 seconds"},"status":1}}`
+	employeesBody = `{"response":{"result":[{"1":[{"Zoho_ID":1,"FirstName":"Ada"}]}],"message":"Data fetched successfully","status":0}}`
+)
+
+// sequence answers the requests with the bodies in turn, repeating the last one, and counts the calls.
+func sequence(calls *int, bodies ...string) func(*http.Request) (*http.Response, error) {
+	return func(*http.Request) (*http.Response, error) {
+		body := bodies[min(*calls, len(bodies)-1)]
+		*calls++
+		return mock.JSONResponse(body), nil
+	}
+}
+
+func newSequenceClient(t *testing.T, roundTrip func(*http.Request) (*http.Response, error), opts ...Option) (*ZohoPeopleClient, *[]time.Duration) {
+	token := oauth2.Token{AccessToken: "token"}
+	c := NewClient(oauth2.StaticTokenSource(&token), mock.HTTPClient(roundTrip), opts...)
+
+	now := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	var waits []time.Duration
+	c.limiter.now = func() time.Time { return now }
+	c.limiter.sleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		now = now.Add(d)
+		return nil
+	}
+	return c, &waits
+}
+
+func TestZohoPeopleClient_RetriesThrottledRequests(t *testing.T) {
+	calls := 0
+	c, waits := newSequenceClient(t, sequence(&calls, throttledBody, employeesBody))
+
+	employees, _, _, err := c.ListUsers(context.Background(), PageOptions{PageSize: 10})
+	require.NoError(t, err)
+	require.Len(t, employees, 1)
+	require.Equal(t, 2, calls)
+	require.Equal(t, []time.Duration{2 * time.Second}, *waits)
+}
+
+func TestZohoPeopleClient_LongLockOutIsHandedToTheSyncer(t *testing.T) {
+	calls := 0
+	c, waits := newSequenceClient(t, sequence(&calls,
+		`{"response":{"message":"Error occurred","errors":{"code":7999,"message":"API call limit exceeded. Try again after 10 minutes"},"status":1}}`,
+	))
+
+	_, _, _, err := c.ListUsers(context.Background(), PageOptions{PageSize: 10})
+	require.Error(t, err)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Empty(t, *waits)
+
+	st, _ := status.FromError(err)
+	require.Len(t, st.Details(), 1)
+	desc, ok := st.Details()[0].(*v2.RateLimitDescription)
+	require.True(t, ok)
+	require.Equal(t, time.Date(2025, 3, 1, 10, 10, 0, 0, time.UTC), desc.ResetAt.AsTime())
+
+	// While locked out the client does not call Zoho at all.
+	_, _, _, err = c.ListUsers(context.Background(), PageOptions{PageSize: 10})
+	require.Error(t, err)
+	require.Equal(t, 1, calls)
+}
+
+func TestZohoPeopleClient_DailyCallBudget(t *testing.T) {
+	calls := 0
+	c, _ := newSequenceClient(t, sequence(&calls, employeesBody), WithDailyCallBudget(2))
+
+	_, _, annos, err := c.ListUsers(context.Background(), PageOptions{PageSize: 10, PageToken: "1"})
+	require.NoError(t, err)
+
+	desc := &v2.RateLimitDescription{}
+	ok, err := annos.Pick(desc)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.EqualValues(t, 2, desc.Limit)
+	require.EqualValues(t, 1, desc.Remaining)
+
+	_, _, _, err = c.ListUsers(context.Background(), PageOptions{PageSize: 10, PageToken: "11"})
+	require.NoError(t, err)
+
+	_, _, _, err = c.ListUsers(context.Background(), PageOptions{PageSize: 10, PageToken: "21"})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.Equal(t, 2, calls)
+}
+
+// newBudgetLimiter returns a rate limiter that keeps its budget at path. The budget saves are
+// queued instead of run on a timer, flush runs them.
+func newBudgetLimiter(path string, budget int64, now *time.Time) (*rateLimiter, func() int) {
+	limiter := newRateLimiter(budget)
+	limiter.store = newEncryptedFile(path, "client-id", "client-secret")
+	limiter.now = func() time.Time { return *now }
+
+	var saves []func()
+	limiter.afterFunc = func(d time.Duration, f func()) {
+		saves = append(saves, f)
+	}
+	flush := func() int {
+		flushed := len(saves)
+		for _, save := range saves {
+			save()
+		}
+		saves = nil
+		return flushed
+	}
+	return limiter, flush
+}
+
+func TestRateLimiter_DailyBudgetCarriesOverRuns(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "token"+budgetFileSuffix)
+	now := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	limiter, flush := newBudgetLimiter(path, 3, &now)
+	require.NoError(t, limiter.acquire(ctx))
+	require.NoError(t, limiter.acquire(ctx))
+	flush()
+
+	// The next run on the same day only gets the rest of the budget.
+	limiter, flush = newBudgetLimiter(path, 3, &now)
+	require.NoError(t, limiter.acquire(ctx))
+	require.Equal(t, codes.ResourceExhausted, status.Code(limiter.acquire(ctx)))
+	flush()
+
+	// A new day starts with the full budget.
+	now = now.Add(24 * time.Hour)
+	limiter, _ = newBudgetLimiter(path, 3, &now)
+	for range 3 {
+		require.NoError(t, limiter.acquire(ctx))
+	}
+}
+
+// Tests that the calls are written to the budget file together rather than one write per call.
+func TestRateLimiter_BatchesBudgetWrites(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "token"+budgetFileSuffix)
+	now := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	limiter, flush := newBudgetLimiter(path, 100, &now)
+	for range 10 {
+		require.NoError(t, limiter.acquire(ctx))
+	}
+	_, err := os.Stat(path)
+	require.True(t, os.IsNotExist(err), "the budget file must not be written before the save runs")
+	require.Equal(t, 1, flush())
+
+	saved, _ := newBudgetLimiter(path, 100, &now)
+	require.NoError(t, saved.loadBudget())
+	require.EqualValues(t, 10, saved.dailyCalls)
+
+	// Calls after the save schedule the next one.
+	require.NoError(t, limiter.acquire(ctx))
+	require.Equal(t, 1, flush())
+	require.NoError(t, saved.loadBudget())
+	require.EqualValues(t, 11, saved.dailyCalls)
+}
+
+func TestNewClient_LeavesTheHTTPClientAlone(t *testing.T) {
+	calls := 0
+	transport := mock.NewTransport(sequence(&calls, employeesBody))
+	httpClient := &http.Client{Transport: transport}
+
+	c := NewClient(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"}), uhttp.NewBaseHttpClient(httpClient))
+	_, _, _, err := c.ListUsers(context.Background(), PageOptions{PageSize: 10})
+	require.NoError(t, err)
+	require.Equal(t, 1, calls)
+	require.Same(t, transport, httpClient.Transport)
+}
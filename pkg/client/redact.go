@@ -0,0 +1,280 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// fieldSet holds the field labels kept in a record. A nil entry keeps the whole value, otherwise
+// the entry lists the fields kept in the nested object or in each object of the nested list.
+type fieldSet map[string]fieldSet
+
+// employeeFields are the employee form fields modelled by Employee. Every other field of an
+// employee record, such as the social security number, date of birth or home address, is dropped
+// unless it is mapped into the user profile.
+var employeeFields = modelledFields(reflect.TypeOf(Employee{}))
+
+// modelledFields returns the JSON fields of the struct type, following nested structs and lists of structs.
+func modelledFields(t reflect.Type) fieldSet {
+	fields := make(fieldSet)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		label, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if label == "-" || !f.IsExported() {
+			continue
+		}
+		if label == "" {
+			label = f.Name
+		}
+
+		nested := f.Type
+		if nested.Kind() == reflect.Slice {
+			nested = nested.Elem()
+		}
+		if nested.Kind() == reflect.Struct {
+			fields[label] = modelledFields(nested)
+		} else {
+			fields[label] = nil
+		}
+	}
+	return fields
+}
+
+// redactor reduces employee records to the allowed fields and strips the fields the organization
+// considers sensitive from every response.
+type redactor struct {
+	mu sync.RWMutex
+	// profileFields are the employee field labels kept on top of the modelled fields.
+	profileFields map[string]struct{}
+	sensitive     map[string]struct{}
+}
+
+func newRedactor() *redactor {
+	return &redactor{
+		profileFields: make(map[string]struct{}),
+		sensitive:     make(map[string]struct{}),
+	}
+}
+
+// WithProfileFields keeps the given employee field labels, e.g. custom fields mapped into the user
+// profile, in addition to the fields modelled by Employee.
+func WithProfileFields(labels ...string) Option {
+	return func(client *ZohoPeopleClient) {
+		client.redactor.mu.Lock()
+		defer client.redactor.mu.Unlock()
+
+		for _, label := range labels {
+			if label = strings.TrimSpace(label); label != "" {
+				client.redactor.profileFields[label] = struct{}{}
+			}
+		}
+	}
+}
+
+// WithSensitiveFields strips the given Zoho field labels from every response, including fields
+// that are modelled or mapped into the user profile.
+func WithSensitiveFields(fields ...string) Option {
+	return func(client *ZohoPeopleClient) {
+		client.redactor.addSensitive(fields...)
+	}
+}
+
+func (r *redactor) addSensitive(fields ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, field := range fields {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field != "" {
+			r.sensitive[field] = struct{}{}
+		}
+	}
+}
+
+// isSensitive reports whether the field label, or the field it is derived from, is sensitive.
+func (r *redactor) isSensitive(label string) bool {
+	label = strings.ToLower(label)
+	if _, ok := r.sensitive[label]; ok {
+		return true
+	}
+
+	base, _, found := strings.Cut(label, ".")
+	if !found {
+		return false
+	}
+	_, ok := r.sensitive[base]
+	return ok
+}
+
+// redactBody reduces the employee records of a getRecords or getDataByID response to the allowed
+// fields and removes the sensitive fields from every object in a JSON document. Bodies that are
+// not JSON objects or arrays are returned unchanged.
+func (r *redactor) redactBody(path string, body []byte) ([]byte, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	// Keep large record IDs such as Zoho_ID intact.
+	decoder.UseNumber()
+
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return body, false
+	}
+
+	r.mu.RLock()
+	changed := false
+	switch {
+	case strings.HasSuffix(path, getEmployeeRecords):
+		changed = r.filterRecords(document, true)
+	case strings.HasSuffix(path, getEmployeeByRecordId):
+		changed = r.filterRecords(document, false)
+	}
+	if r.redactValue(document) {
+		changed = true
+	}
+	r.mu.RUnlock()
+
+	if !changed {
+		return body, false
+	}
+
+	redacted, err := json.Marshal(document)
+	if err != nil {
+		return body, false
+	}
+
+	return redacted, true
+}
+
+// filterRecords reduces the records in the result of a forms response to the allowed fields.
+// getRecords keys each record by its ID, getDataByID lists the records directly.
+func (r *redactor) filterRecords(document any, keyedByID bool) bool {
+	envelope, _ := document.(map[string]any)
+	response, _ := envelope["response"].(map[string]any)
+	result, _ := response["result"].([]any)
+
+	changed := false
+	for _, item := range result {
+		item, _ := item.(map[string]any)
+		if !keyedByID {
+			if r.filterRecord(item, employeeFields, true) {
+				changed = true
+			}
+			continue
+		}
+
+		for _, records := range item {
+			records, _ := records.([]any)
+			for _, record := range records {
+				record, _ := record.(map[string]any)
+				if r.filterRecord(record, employeeFields, true) {
+					changed = true
+				}
+			}
+		}
+	}
+	return changed
+}
+
+// filterRecord removes the fields of the record that are not allowed. The profile fields are only
+// allowed at the top level of a record.
+func (r *redactor) filterRecord(record map[string]any, allowed fieldSet, topLevel bool) bool {
+	changed := false
+	for label, value := range record {
+		nested, ok := allowed[label]
+		if !ok && topLevel {
+			_, ok = r.profileFields[label]
+		}
+		if !ok {
+			delete(record, label)
+			changed = true
+			continue
+		}
+		if nested == nil {
+			continue
+		}
+
+		switch v := value.(type) {
+		case map[string]any:
+			if r.filterRecord(v, nested, false) {
+				changed = true
+			}
+		case []any:
+			for _, child := range v {
+				if child, ok := child.(map[string]any); ok && r.filterRecord(child, nested, false) {
+					changed = true
+				}
+			}
+		}
+	}
+	return changed
+}
+
+func (r *redactor) redactValue(value any) bool {
+	changed := false
+
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if r.isSensitive(key) {
+				delete(v, key)
+				changed = true
+				continue
+			}
+			if r.redactValue(child) {
+				changed = true
+			}
+		}
+	case []any:
+		for _, child := range v {
+			if r.redactValue(child) {
+				changed = true
+			}
+		}
+	}
+
+	return changed
+}
+
+// redactTransport strips the fields that are not allowed from JSON responses before anything else
+// sees them, so they are not decoded, cached by uhttp or printed by its response body debugging.
+type redactTransport struct {
+	base     http.RoundTripper
+	redactor *redactor
+}
+
+func (t *redactTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp == nil || resp.Body == nil {
+		return resp, err
+	}
+
+	if !strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if redacted, ok := t.redactor.redactBody(req.URL.Path, body); ok {
+		body = redacted
+		resp.Header = resp.Header.Clone()
+		resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		resp.ContentLength = int64(len(body))
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	return resp, nil
+}
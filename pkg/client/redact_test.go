@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/conductorone/baton-zoho-people/test/mock"
+	"golang.org/x/oauth2"
+)
+
+func TestRedactBody(t *testing.T) {
+	r := newRedactor()
+	r.profileFields["Cost_Center"] = struct{}{}
+	r.addSensitive("Work_phone")
+
+	body := []byte(`{"response":{"result":[{"858578000000277092":[{
+		"Zoho_ID": 858578000000277092,
+		"FirstName": "Christopher",
+		"Role.ID": "858578000000035639",
+		"Cost_Center": "CC-42",
+		"Work_phone": "555-0100",
+		"Social_Security_Number": "123-45-6789",
+		"Marital_status.id": "1",
+		"Present_Address.childValues": {"CITY": "Tampa"},
+		"Salary": "100000",
+		"tabularSections": {
+			"Dependent Details": [{"Name": "Jane"}],
+			"Education Details": [{"Degree": "BSc", "Grade": "A"}]
+		}
+	}]}],"status":0}}`)
+
+	redacted, ok := r.redactBody("/people/api/forms"+getEmployeeRecords, body)
+	if !ok {
+		t.Fatal("Expected the body to be redacted")
+	}
+
+	var envelope struct {
+		Response struct {
+			Result []map[string][]map[string]any `json:"result"`
+		} `json:"response"`
+	}
+	if err := json.Unmarshal(redacted, &envelope); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	fields := envelope.Response.Result[0]["858578000000277092"][0]
+
+	// Only the modelled fields and the profile fields are kept, sensitive fields are stripped even when modelled.
+	var labels []string
+	for label := range fields {
+		labels = append(labels, label)
+	}
+	slices.Sort(labels)
+	if want := []string{"Cost_Center", "FirstName", "Role.ID", "Zoho_ID", "tabularSections"}; !slices.Equal(labels, want) {
+		t.Errorf("Expected the fields %v, got %v", want, labels)
+	}
+
+	sections, _ := fields["tabularSections"].(map[string]any)
+	if _, ok := sections["Dependent Details"]; ok || len(sections) != 1 {
+		t.Errorf("Expected only the education details to be kept, got %v", sections)
+	}
+	education, _ := sections["Education Details"].([]any)
+	if len(education) != 1 || !reflect.DeepEqual(education[0], map[string]any{"Degree": "BSc"}) {
+		t.Errorf("Expected only the modelled education fields to be kept, got %v", education)
+	}
+
+	var records struct {
+		Response struct {
+			Result []map[string][]Employee `json:"result"`
+		} `json:"response"`
+	}
+	if err := json.Unmarshal(redacted, &records); err != nil {
+		t.Fatalf("Expected valid employee records, got %v", err)
+	}
+	employees := records.Response.Result[0]["858578000000277092"]
+	if len(employees) != 1 || employees[0].ZohoID != 858578000000277092 || employees[0].FirstName != "Christopher" {
+		t.Errorf("Expected the remaining fields to survive, got %+v", employees)
+	}
+	if string(employees[0].Fields["Cost_Center"]) != `"CC-42"` {
+		t.Errorf("Expected the profile field to be decoded, got %s", employees[0].Fields["Cost_Center"])
+	}
+}
+
+// Tests that a single employee record is reduced like the records of a listing, and that the records
+// of other forms only lose the sensitive fields.
+func TestRedactBody_Forms(t *testing.T) {
+	r := newRedactor()
+	r.addSensitive("Salary")
+
+	redacted, ok := r.redactBody("/people/api/forms"+getEmployeeByRecordId, []byte(`{"response":{"result":[{
+		"Zoho_ID": 858578000000277092,
+		"Date_of_birth": "01-Jan-1980"
+	}],"status":0}}`))
+	if !ok || strings.Contains(string(redacted), "Date_of_birth") {
+		t.Errorf("Expected the date of birth to be stripped, got %s", redacted)
+	}
+
+	redacted, ok = r.redactBody("/people/api/forms"+getDepartmentRecords, []byte(`{"response":{"result":[{"1":[{
+		"Zoho_ID": 1,
+		"Location": "Berlin",
+		"Salary": "100000"
+	}]}],"status":0}}`))
+	if !ok || strings.Contains(string(redacted), "Salary") || !strings.Contains(string(redacted), "Berlin") {
+		t.Errorf("Expected only the sensitive department field to be stripped, got %s", redacted)
+	}
+}
+
+func TestRedactBody_Unchanged(t *testing.T) {
+	r := newRedactor()
+
+	for _, body := range []string{`not json`, `{"response":{"result":[],"status":0}}`} {
+		if _, ok := r.redactBody("/people/api/forms"+getEmployeeRecords, []byte(body)); ok {
+			t.Errorf("Expected %q to be left alone", body)
+		}
+	}
+}
+
+// Tests that the client only decodes the profile fields it was given on top of the modelled fields.
+func TestZohoPeopleClient_ListUsersKeepsProfileFields(t *testing.T) {
+	body := `{"response":{"result":[{"1":[{
+		"Zoho_ID": 1,
+		"Cost_Center": "CC-42",
+		"Badge_ID": "B-7",
+		"Ethnicity": "Other"
+	}]}],"status":0}}`
+	c := NewClient(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"}),
+		mock.HTTPClient(func(*http.Request) (*http.Response, error) {
+			return mock.JSONResponse(body), nil
+		}),
+		WithProfileFields("Cost_Center"))
+
+	employees, _, _, err := c.ListUsers(context.Background(), PageOptions{PageSize: 10})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(employees) != 1 {
+		t.Fatalf("Expected one employee, got %d", len(employees))
+	}
+
+	var labels []string
+	for label := range employees[0].Fields {
+		labels = append(labels, label)
+	}
+	slices.Sort(labels)
+	if want := []string{"Cost_Center", "Zoho_ID"}; !slices.Equal(labels, want) {
+		t.Errorf("Expected the fields %v, got %v", want, labels)
+	}
+}
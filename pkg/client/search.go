@@ -0,0 +1,97 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// searchOperators are the search operators getRecords accepts, keyed by their lower case name.
+var searchOperators = map[string]string{
+	"is":           "Is",
+	"is_not":       "Is_Not",
+	"contains":     "Contains",
+	"not_contains": "Not_Contains",
+	"starts_with":  "Starts_With",
+	"ends_with":    "Ends_With",
+	"is_empty":     "Is_Empty",
+	"is_not_empty": "Is_Not_Empty",
+	"before":       "Before",
+	"after":        "After",
+	"between":      "Between",
+	"less_than":    "Less_Than",
+	"greater_than": "Greater_Than",
+}
+
+// SearchParam is a getRecords search criterion, e.g. Employeestatus Is Active.
+type SearchParam struct {
+	Field    string
+	Operator string
+	Text     string
+}
+
+// ParseSearchParam parses a Field:Operator:Text expression such as "Employeestatus:Is:Active".
+// The text may contain colons and is omitted for Is_Empty and Is_Not_Empty.
+func ParseSearchParam(expression string) (SearchParam, error) {
+	parts := strings.SplitN(expression, ":", 3)
+	if len(parts) < 2 {
+		return SearchParam{}, fmt.Errorf("invalid search expression %q: expected Field:Operator:Text", expression)
+	}
+
+	param := SearchParam{Field: strings.TrimSpace(parts[0])}
+	if param.Field == "" {
+		return SearchParam{}, fmt.Errorf("invalid search expression %q: the field is empty", expression)
+	}
+
+	operator, ok := searchOperators[strings.ToLower(strings.TrimSpace(parts[1]))]
+	if !ok {
+		return SearchParam{}, fmt.Errorf("invalid search expression %q: unknown operator %q", expression, parts[1])
+	}
+	param.Operator = operator
+
+	if len(parts) == 3 {
+		param.Text = strings.TrimSpace(parts[2])
+	}
+	if param.Text == "" && operator != "Is_Empty" && operator != "Is_Not_Empty" {
+		return SearchParam{}, fmt.Errorf("invalid search expression %q: %s needs a search text", expression, operator)
+	}
+
+	return param, nil
+}
+
+// ParseSearchParams parses a list of Field:Operator:Text expressions.
+func ParseSearchParams(expressions []string) ([]SearchParam, error) {
+	params := make([]SearchParam, 0, len(expressions))
+	for _, expression := range expressions {
+		param, err := ParseSearchParam(expression)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, param)
+	}
+
+	return params, nil
+}
+
+func (p SearchParam) String() string {
+	return fmt.Sprintf("{searchField:'%s',searchOperator:'%s',searchText:'%s'}",
+		escapeSearchValue(p.Field), p.Operator, escapeSearchValue(p.Text))
+}
+
+func escapeSearchValue(value string) string {
+	return strings.ReplaceAll(value, "'", `\'`)
+}
+
+// WithSearchParams restricts getRecords to the records matching all the criteria.
+func WithSearchParams(params []SearchParam) ReqOpt {
+	if len(params) == 0 {
+		return func(*url.URL) {}
+	}
+
+	criteria := make([]string, 0, len(params))
+	for _, param := range params {
+		criteria = append(criteria, param.String())
+	}
+
+	return WithQueryParam("searchParams", strings.Join(criteria, "|"))
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSearchParam(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   SearchParam
+		wantErr    bool
+	}{
+		{expression: "Employeestatus:Is:Active", expected: SearchParam{Field: "Employeestatus", Operator: "Is", Text: "Active"}},
+		{expression: "LocationName:starts_with:Berlin: Mitte", expected: SearchParam{Field: "LocationName", Operator: "Starts_With", Text: "Berlin: Mitte"}},
+		{expression: "Dateofexit:Is_Empty", expected: SearchParam{Field: "Dateofexit", Operator: "Is_Empty"}},
+		{expression: "Employeestatus", wantErr: true},
+		{expression: "Employeestatus:Equals:Active", wantErr: true},
+		{expression: "Employeestatus:Is", wantErr: true},
+		{expression: ":Is:Active", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			param, err := ParseSearchParam(tt.expression)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, param)
+		})
+	}
+}
+
+func TestWithSearchParams(t *testing.T) {
+	u := &url.URL{}
+	WithSearchParams([]SearchParam{
+		{Field: "Employeestatus", Operator: "Is", Text: "Active"},
+		{Field: "Legal_Entity", Operator: "Is", Text: "Zylker's GmbH"},
+	})(u)
+
+	require.Equal(t,
+		`{searchField:'Employeestatus',searchOperator:'Is',searchText:'Active'}|{searchField:'Legal_Entity',searchOperator:'Is',searchText:'Zylker\'s GmbH'}`,
+		u.Query().Get("searchParams"))
+
+	u = &url.URL{}
+	WithSearchParams(nil)(u)
+	require.Empty(t, u.RawQuery)
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// tokenCache persists the Zoho token response on disk so unattended syncs keep refreshing
+// access tokens without a new grant code. The file is encrypted with AES-GCM using a key
+// derived from the OAuth client credentials.
+type tokenCache struct {
+	file *encryptedFile
+}
+
+type cachedToken struct {
+	TokenResponse
+	Expiry time.Time `json:"expiry"`
+}
+
+func newTokenCache(path, clientID, clientSecret string) *tokenCache {
+	return &tokenCache{file: newEncryptedFile(path, clientID, clientSecret)}
+}
+
+// Load returns the cached token, or nil when nothing has been cached yet.
+func (c *tokenCache) Load() (*oauth2.Token, error) {
+	plaintext, err := c.file.read()
+	if err != nil || plaintext == nil {
+		return nil, err
+	}
+
+	var ct cachedToken
+	if err := json.Unmarshal(plaintext, &ct); err != nil {
+		return nil, err
+	}
+
+	if ct.RefreshToken == "" {
+		return nil, nil
+	}
+
+	token := &oauth2.Token{
+		AccessToken:  ct.AccessToken,
+		RefreshToken: ct.RefreshToken,
+		TokenType:    ct.TokenType,
+		Expiry:       ct.Expiry,
+	}
+	return token.WithExtra(map[string]interface{}{
+		"api_domain": ct.ApiDomain,
+		"scope":      ct.Scope,
+	}), nil
+}
+
+// Save atomically replaces the cached token.
+func (c *tokenCache) Save(token *oauth2.Token) error {
+	ct := cachedToken{
+		TokenResponse: TokenResponse{
+			AccessToken:  token.AccessToken,
+			RefreshToken: token.RefreshToken,
+			Scope:        tokenExtra(token, "scope"),
+			ApiDomain:    tokenExtra(token, "api_domain"),
+			TokenType:    token.TokenType,
+		},
+		Expiry: token.Expiry,
+	}
+	if !token.Expiry.IsZero() {
+		ct.ExpiresIn = int(time.Until(token.Expiry).Seconds())
+	}
+
+	plaintext, err := json.Marshal(ct)
+	if err != nil {
+		return err
+	}
+
+	return c.file.write(plaintext)
+}
+
+func tokenExtra(token *oauth2.Token, key string) string {
+	value, _ := token.Extra(key).(string)
+	return value
+}
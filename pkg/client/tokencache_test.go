@@ -0,0 +1,39 @@
+package client
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestTokenCache_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	cache := newTokenCache(path, "client-id", "client-secret")
+
+	token, err := cache.Load()
+	require.NoError(t, err)
+	require.Nil(t, token)
+
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+	saved := (&oauth2.Token{
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		TokenType:    "Bearer",
+		Expiry:       expiry,
+	}).WithExtra(map[string]interface{}{"api_domain": "https://www.zohoapis.eu"})
+	require.NoError(t, cache.Save(saved))
+
+	token, err = cache.Load()
+	require.NoError(t, err)
+	require.Equal(t, "access", token.AccessToken)
+	require.Equal(t, "refresh", token.RefreshToken)
+	require.True(t, expiry.Equal(token.Expiry))
+	require.Equal(t, "https://www.zohoapis.eu", token.Extra("api_domain"))
+
+	// A cache written with other client credentials cannot be read.
+	_, err = newTokenCache(path, "client-id", "rotated-secret").Load()
+	require.Error(t, err)
+}
@@ -2,6 +2,7 @@ package connector
 
 import (
 	"context"
+	"fmt"
 	"io"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
@@ -11,62 +12,206 @@ import (
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
 	"go.uber.org/zap"
 	"golang.org/x/oauth2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Connector struct {
-	client *client.ZohoPeopleClient
+	client     *client.ZohoPeopleClient
+	clientOpts []client.Option
+	employees  *employeeCache
+	// profileFields maps Zoho field labels to user profile keys.
+	profileFields map[string]string
+	// defaultRoleID is the Zoho role ID assigned when a role is revoked.
+	defaultRoleID string
+	// fallbackDepartmentID is the Zoho department ID assigned when a department membership is revoked.
+	fallbackDepartmentID string
+	offboarding          offboarding
+	offboardRevokeRole   bool
+	// scope restricts the sync to the matching employees and departments.
+	scope syncScope
 }
 
 type Option func(*Connector) error
 
+// WithClientOptions passes options through to the Zoho People client.
+func WithClientOptions(opts ...client.Option) Option {
+	return func(c *Connector) error {
+		c.clientOpts = append(c.clientOpts, opts...)
+		return nil
+	}
+}
+
+// WithProfileFieldMapping copies the Zoho employee fields, keyed by field label, into the user
+// profile under the given key. Custom fields of the Employee form can be mapped as well.
+func WithProfileFieldMapping(mapping map[string]string) Option {
+	return func(c *Connector) error {
+		if c.profileFields == nil {
+			c.profileFields = make(map[string]string, len(mapping))
+		}
+		for label, key := range mapping {
+			if label == "" || key == "" {
+				return fmt.Errorf("baton-zoho-people: invalid profile field mapping %q=%q", label, key)
+			}
+			c.profileFields[label] = key
+		}
+		return nil
+	}
+}
+
+// WithDefaultRoleID sets the role employees are moved to when their role is revoked.
+func WithDefaultRoleID(roleID string) Option {
+	return func(c *Connector) error {
+		c.defaultRoleID = roleID
+		return nil
+	}
+}
+
+// WithFallbackDepartmentID sets the department employees are moved to when their membership is revoked.
+func WithFallbackDepartmentID(departmentID string) Option {
+	return func(c *Connector) error {
+		c.fallbackDepartmentID = departmentID
+		return nil
+	}
+}
+
+// WithOffboarding configures how deleting a user offboards the employee. status is the employee
+// status to set, e.g. Resigned or Terminated. With revokeRole the employee is moved to the default
+// role and with clearManager the reporting manager is removed.
+func WithOffboarding(status string, revokeRole, clearManager bool) Option {
+	return func(c *Connector) error {
+		c.offboarding.status = status
+		c.offboarding.clearManager = clearManager
+		c.offboardRevokeRole = revokeRole
+		return nil
+	}
+}
+
+// WithEmployeeFilters only syncs the employees matching all the search criteria. Role assignments,
+// department members and managers outside of the scope are left out as well.
+func WithEmployeeFilters(filters ...client.SearchParam) Option {
+	return func(c *Connector) error {
+		c.scope.employees = append(c.scope.employees, filters...)
+		return nil
+	}
+}
+
+// WithDepartmentFilters only syncs the departments matching all the search criteria. A matching
+// department whose parent does not match is synced at the top level.
+func WithDepartmentFilters(filters ...client.SearchParam) Option {
+	return func(c *Connector) error {
+		c.scope.departments = append(c.scope.departments, filters...)
+		return nil
+	}
+}
+
 func (d *Connector) SetTokenSource(tokenSource oauth2.TokenSource) {
 	d.client.TokenSource = tokenSource
 }
 
 // ResourceSyncers returns a ResourceSyncer for each resource type that should be synced from the upstream service.
 func (d *Connector) ResourceSyncers(ctx context.Context) []connectorbuilder.ResourceSyncer {
+	offboarding := d.offboarding
+	if d.offboardRevokeRole {
+		offboarding.roleID = d.defaultRoleID
+	}
+
 	return []connectorbuilder.ResourceSyncer{
-		newUserBuilder(d.client),
-		newRoleBuilder(d.client),
+		newUserBuilder(d.client, d.employees, d.profileFields, offboarding),
+		newRoleBuilder(d.client, d.employees, d.defaultRoleID),
+		newDepartmentBuilder(d.client, d.employees, d.fallbackDepartmentID),
 	}
 }
 
 // Asset takes an input AssetRef and attempts to fetch it using the connector's authenticated http client
 // It streams a response, always starting with a metadata object, following by chunked payloads for the asset.
+// The asset ID of a user icon is the file name of the employee photo in Zoho.
 func (d *Connector) Asset(ctx context.Context, asset *v2.AssetRef) (string, io.ReadCloser, error) {
-	return "", nil, nil
+	if asset.GetId() == "" {
+		return "", nil, status.Error(codes.InvalidArgument, "baton-zoho-people: asset id is required")
+	}
+
+	contentType, photo, err := d.client.GetEmployeePhoto(ctx, asset.GetId())
+	if err != nil {
+		return "", nil, fmt.Errorf("baton-zoho-people: error fetching employee photo: %w", err)
+	}
+
+	return contentType, photo, nil
+}
+
+// accountCreationSchema lists the employee fields CreateAccount reads from the account profile.
+var accountCreationSchema = &v2.ConnectorAccountCreationSchema{
+	FieldMap: map[string]*v2.ConnectorAccountCreationSchema_Field{
+		"first_name":    accountCreationStringField("First name", "", true, 1),
+		"last_name":     accountCreationStringField("Last name", "", true, 2),
+		"email":         accountCreationStringField("Email", "Work email address of the employee", true, 3),
+		"employee_id":   accountCreationStringField("Employee ID", "", true, 4),
+		"department_id": accountCreationStringField("Department ID", "Zoho ID of the department", false, 5),
+		"role_id":       accountCreationStringField("Role ID", "Zoho ID of the role", false, 6),
+		"manager_id":    accountCreationStringField("Manager ID", "Zoho ID of the employee the new hire reports to", false, 7),
+		"joining_date":  accountCreationStringField("Joining date", "Date of joining as YYYY-MM-DD", false, 8),
+	},
+}
+
+func accountCreationStringField(displayName, description string, required bool, order int32) *v2.ConnectorAccountCreationSchema_Field {
+	return &v2.ConnectorAccountCreationSchema_Field{
+		DisplayName: displayName,
+		Description: description,
+		Required:    required,
+		Order:       order,
+		Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
+			StringField: &v2.ConnectorAccountCreationSchema_StringField{},
+		},
+	}
 }
 
 // Metadata returns metadata about the connector.
 func (d *Connector) Metadata(ctx context.Context) (*v2.ConnectorMetadata, error) {
 	return &v2.ConnectorMetadata{
-		DisplayName: "My Baton Connector",
-		Description: "The template implementation of a baton connector",
+		DisplayName:           "Zoho People",
+		Description:           "Syncs employees, roles and departments from Zoho People",
+		AccountCreationSchema: accountCreationSchema,
 	}, nil
 }
 
 // Validate is called to ensure that the connector is properly configured. It should exercise any API credentials
 // to be sure that they are valid.
 func (d *Connector) Validate(ctx context.Context) (annotations.Annotations, error) {
-	return nil, nil
+	// Zoho reports missing scopes and permissions inside successful responses, so read a single
+	// employee to make sure the credentials can actually see the data we sync.
+	_, _, annos, err := d.client.ListUsers(ctx, client.PageOptions{PageSize: 1})
+	if err != nil {
+		return annos, fmt.Errorf("baton-zoho-people: error validating credentials: %w", err)
+	}
+
+	if err := validateSchema(ctx, d.client, d.profileFields, d.scope); err != nil {
+		return annos, err
+	}
+
+	return annos, nil
 }
 
 // New returns a new instance of the connector.
-func New(ctx context.Context, zohoClientID, zohoSecretID, zohoCode, domainAccount string) (*Connector, error) {
+func New(ctx context.Context, authData client.ZohoAuthData, opts ...Option) (*Connector, error) {
 	l := ctxzap.Extract(ctx)
 
-	zohoPeopleClient, err := client.New(ctx, client.ZohoAuthData{
-		ClientID:      zohoClientID,
-		ClientSecret:  zohoSecretID,
-		ClientCode:    zohoCode,
-		DomainAccount: domainAccount,
-	})
+	connector := &Connector{}
+	for _, opt := range opts {
+		if err := opt(connector); err != nil {
+			return nil, err
+		}
+	}
+
+	// Employee records are reduced to the modelled fields, keep the fields mapped into the profile.
+	connector.clientOpts = append(connector.clientOpts, client.WithProfileFields(sortedKeys(connector.profileFields)...))
+	zohoPeopleClient, err := client.New(ctx, authData, connector.clientOpts...)
 	if err != nil {
 		l.Error("error creating Zoho People client", zap.Error(err))
 		return nil, err
 	}
+	connector.client = zohoPeopleClient
+	connector.employees = newEmployeeCache(zohoPeopleClient)
+	connector.employees.scope = connector.scope
 
-	return &Connector{
-		client: zohoPeopleClient,
-	}, nil
+	return connector, nil
 }
@@ -0,0 +1,323 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	"github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-zoho-people/pkg/client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	departmentMemberEntitlement = "member"
+	departmentLeadEntitlement   = "lead"
+)
+
+type departmentBuilder struct {
+	resourceType *v2.ResourceType
+	client       *client.ZohoPeopleClient
+	employees    *employeeCache
+	// fallbackDepartmentID is the department an employee is moved to when their membership is revoked.
+	fallbackDepartmentID string
+}
+
+func (o *departmentBuilder) ResourceType(_ context.Context) *v2.ResourceType {
+	return departmentResourceType
+}
+
+// List returns the departments nested directly under the parent department, or the top level
+// departments when there is no parent, so the resources mirror the Zoho department hierarchy.
+// A department whose parent is not synced is listed at the top level.
+func (o *departmentBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	var resources []*v2.Resource
+
+	bag, pageToken, err := getToken(pToken, departmentResourceType)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	parentID := ""
+	if parentResourceID != nil {
+		parentID = parentResourceID.Resource
+	}
+
+	children, err := o.employees.ChildDepartments(ctx, parentID)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	departments, nextPageToken, err := pageOf(children, pageToken, pToken.Size)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	err = bag.Next(nextPageToken)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	for _, department := range departments {
+		departmentCopy := department
+		departmentResource, err := parseIntoDepartmentResource(&departmentCopy, parentResourceID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		resources = append(resources, departmentResource)
+	}
+
+	nextPageToken, err = bag.Marshal()
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return resources, nextPageToken, nil, nil
+}
+
+// Entitlements returns the membership and lead entitlements of a department.
+// The lead approves leave, timesheets and attendance of the department, so it is modelled as a permission.
+func (o *departmentBuilder) Entitlements(_ context.Context, res *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	memberOptions := []entitlement.EntitlementOption{
+		entitlement.WithGrantableTo(userResourceType),
+		entitlement.WithDescription(fmt.Sprintf("Member of the %s department", res.DisplayName)),
+		entitlement.WithDisplayName(fmt.Sprintf("%s department member", res.DisplayName)),
+	}
+
+	leadOptions := []entitlement.EntitlementOption{
+		entitlement.WithGrantableTo(userResourceType),
+		entitlement.WithDescription(fmt.Sprintf("Lead of the %s department", res.DisplayName)),
+		entitlement.WithDisplayName(fmt.Sprintf("%s department lead", res.DisplayName)),
+	}
+
+	return []*v2.Entitlement{
+		entitlement.NewAssignmentEntitlement(res, departmentMemberEntitlement, memberOptions...),
+		entitlement.NewPermissionEntitlement(res, departmentLeadEntitlement, leadOptions...),
+	}, "", nil, nil
+}
+
+// Grants returns a membership grant for every employee assigned to the department and a lead grant
+// for the department lead. Zoho keeps the membership on the employee record, so the members come
+// from the employee index of the sync.
+func (o *departmentBuilder) Grants(ctx context.Context, res *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	var grants []*v2.Grant
+
+	bag, pageToken, err := getToken(pToken, departmentResourceType)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	// The lead comes from the department record itself, emit it once with the first page.
+	if pageToken == "" {
+		leadGrant, err := getDepartmentLeadGrant(res)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		if leadGrant != nil {
+			inScope, err := o.employees.InScope(ctx, leadGrant.Principal.Id.Resource)
+			if err != nil {
+				return nil, "", nil, err
+			}
+			if inScope {
+				grants = append(grants, leadGrant)
+			}
+		}
+	}
+
+	index, err := o.employees.Index(ctx)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	userIDs, nextPageToken, err := pageOf(index.DepartmentMembers(res.Id.Resource), pageToken, pToken.Size)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	err = bag.Next(nextPageToken)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	for _, userID := range userIDs {
+		grants = append(grants, newDepartmentMemberGrant(res, userID))
+	}
+
+	nextPageToken, err = bag.Marshal()
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return grants, nextPageToken, nil, nil
+}
+
+// Grant moves the employee into the department. An employee belongs to a single department in
+// Zoho People, so the previous membership is replaced and reported in the grant metadata.
+func (o *departmentBuilder) Grant(ctx context.Context, principal *v2.Resource, ent *v2.Entitlement) ([]*v2.Grant, annotations.Annotations, error) {
+	if principal.Id.ResourceType != userResourceType.Id {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "baton-zoho-people: departments can only be granted to users, got %s", principal.Id.ResourceType)
+	}
+	if !strings.HasSuffix(ent.Id, ":"+departmentMemberEntitlement) {
+		return nil, nil, status.Error(codes.Unimplemented, "baton-zoho-people: only department membership can be granted, the lead is set on the department")
+	}
+
+	userID := principal.Id.Resource
+	departmentID := ent.Resource.Id.Resource
+
+	employee, err := getEmployee(ctx, o.client, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if employee.DepartmentID == departmentID {
+		return []*v2.Grant{newDepartmentMemberGrant(ent.Resource, userID)}, annotations.New(&v2.GrantAlreadyExists{}), nil
+	}
+
+	annos, err := o.client.UpdateEmployee(ctx, userID, map[string]string{"Department": departmentID})
+	if err != nil {
+		return nil, nil, err
+	}
+	o.employees.Reset()
+
+	var grantOptions []grant.GrantOption
+	if employee.DepartmentID != "" {
+		replaced := newDepartmentMemberGrant(&v2.Resource{
+			Id: &v2.ResourceId{
+				ResourceType: departmentResourceType.Id,
+				Resource:     employee.DepartmentID,
+			},
+		}, userID)
+
+		grantOptions = append(grantOptions, grant.WithGrantMetadata(map[string]interface{}{
+			"revoked_grant_id":      replaced.Id,
+			"revoked_department_id": employee.DepartmentID,
+			"revoked_department":    employee.Department,
+		}))
+	}
+
+	return []*v2.Grant{newDepartmentMemberGrant(ent.Resource, userID, grantOptions...)}, annos, nil
+}
+
+// Revoke moves the employee to the configured fallback department, since every employee belongs to a department.
+func (o *departmentBuilder) Revoke(ctx context.Context, g *v2.Grant) (annotations.Annotations, error) {
+	userID := g.Principal.Id.Resource
+	departmentID := g.Entitlement.Resource.Id.Resource
+
+	if !strings.HasSuffix(g.Entitlement.Id, ":"+departmentMemberEntitlement) {
+		return nil, status.Error(codes.Unimplemented, "baton-zoho-people: only department membership can be revoked, the lead is set on the department")
+	}
+	if o.fallbackDepartmentID == "" {
+		return nil, status.Error(codes.FailedPrecondition, "baton-zoho-people: a fallback department is required to revoke department membership")
+	}
+	if departmentID == o.fallbackDepartmentID {
+		return nil, status.Errorf(codes.FailedPrecondition, "baton-zoho-people: membership of the fallback department %s cannot be revoked", departmentID)
+	}
+
+	employee, err := getEmployee(ctx, o.client, userID)
+	if err != nil {
+		return nil, err
+	}
+	if employee.DepartmentID != departmentID {
+		return annotations.New(&v2.GrantAlreadyRevoked{}), nil
+	}
+
+	annos, err := o.client.UpdateEmployee(ctx, userID, map[string]string{"Department": o.fallbackDepartmentID})
+	if err != nil {
+		return nil, err
+	}
+	o.employees.Reset()
+
+	return annos, nil
+}
+
+func newDepartmentMemberGrant(res *v2.Resource, userID string, opts ...grant.GrantOption) *v2.Grant {
+	departmentID := res.Id.Resource
+	opts = append(opts, grant.WithAnnotation(&v2.V1Identifier{
+		Id: fmt.Sprintf("department-grant:%s:%s:%s", departmentID, userID, departmentMemberEntitlement),
+	}))
+
+	return grant.NewGrant(
+		res,
+		departmentMemberEntitlement,
+		&v2.ResourceId{
+			ResourceType: userResourceType.Id,
+			Resource:     userID,
+		},
+		opts...,
+	)
+}
+
+func getDepartmentLeadGrant(res *v2.Resource) (*v2.Grant, error) {
+	groupTrait, err := resource.GetGroupTrait(res)
+	if err != nil {
+		return nil, err
+	}
+
+	leadID, ok := resource.GetProfileStringValue(groupTrait.Profile, "department_lead_id")
+	if !ok || leadID == "" {
+		return nil, nil
+	}
+
+	departmentID := res.Id.Resource
+	return grant.NewGrant(
+		res,
+		departmentLeadEntitlement,
+		&v2.ResourceId{
+			ResourceType: userResourceType.Id,
+			Resource:     leadID,
+		},
+		grant.WithAnnotation(&v2.V1Identifier{
+			Id: fmt.Sprintf("department-grant:%s:%s:%s", departmentID, leadID, departmentLeadEntitlement),
+		}),
+	), nil
+}
+
+func parseIntoDepartmentResource(department *client.Department, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"department_name":       department.Department,
+		"mail_alias":            department.MailAlias,
+		"parent_department":     department.ParentDepartment,
+		"department_lead":       department.DepartmentLead,
+		"department_lead_id":    department.DepartmentLeadID,
+		"department_lead_email": department.DepartmentLeadMail,
+	}
+
+	groupTraits := []resource.GroupTraitOption{
+		resource.WithGroupProfile(profile),
+	}
+
+	resourceOptions := []resource.ResourceOption{
+		resource.WithAnnotation(&v2.ChildResourceType{ResourceTypeId: departmentResourceType.Id}),
+	}
+	if parentResourceID != nil {
+		resourceOptions = append(resourceOptions, resource.WithParentResourceID(parentResourceID))
+	}
+
+	ret, err := resource.NewGroupResource(
+		department.Department,
+		departmentResourceType,
+		department.ZohoID.String(),
+		groupTraits,
+		resourceOptions...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func newDepartmentBuilder(c *client.ZohoPeopleClient, employees *employeeCache, fallbackDepartmentID string) *departmentBuilder {
+	return &departmentBuilder{
+		resourceType:         departmentResourceType,
+		client:               c,
+		employees:            employees,
+		fallbackDepartmentID: fallbackDepartmentID,
+	}
+}
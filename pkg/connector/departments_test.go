@@ -0,0 +1,316 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-zoho-people/pkg/client"
+	"github.com/conductorone/baton-zoho-people/test"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newDepartmentsResponse() *http.Response {
+	mockResponse := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader(test.ReadFile("departmentsMock.json"))),
+	}
+	mockResponse.Header.Set("Content-Type", "application/json")
+	return mockResponse
+}
+
+// Tests that departments are synced as groups with their Zoho record in the profile.
+func TestDepartmentBuilder_List(t *testing.T) {
+	ctx := context.Background()
+
+	testClient := test.NewTestClient(newDepartmentsResponse(), nil)
+	d := newDepartmentBuilder(testClient, newEmployeeCache(testClient), "")
+	departments, nextPageToken, _, err := d.List(ctx, nil, &pagination.Token{Size: 10})
+	require.NoError(t, err)
+	require.Empty(t, nextPageToken)
+	require.Len(t, departments, 1)
+
+	management := departments[0]
+	require.Equal(t, "Management", management.DisplayName)
+	require.Equal(t, departmentResourceType.Id, management.Id.ResourceType)
+	require.Equal(t, "858578000000277092", management.Id.Resource)
+
+	groupTrait, err := resource.GetGroupTrait(management)
+	require.NoError(t, err)
+	profile := groupTrait.Profile.GetFields()
+	require.Equal(t, "management@zylker.com", profile["mail_alias"].GetStringValue())
+	require.Equal(t, "Christopher Brown S20", profile["department_lead"].GetStringValue())
+}
+
+// Tests that every department offers a membership entitlement to users.
+func TestDepartmentBuilder_Entitlements(t *testing.T) {
+	ctx := context.Background()
+
+	d := newDepartmentBuilder(nil, nil, "")
+	sales := &v2.Resource{
+		Id:          &v2.ResourceId{ResourceType: departmentResourceType.Id, Resource: "858578000000277093"},
+		DisplayName: "Sales",
+	}
+	entitlements, _, _, err := d.Entitlements(ctx, sales, &pagination.Token{})
+	require.NoError(t, err)
+
+	member := entitlements[0]
+	require.Equal(t, "department:858578000000277093:member", member.Id)
+	require.Equal(t, v2.Entitlement_PURPOSE_VALUE_ASSIGNMENT, member.Purpose)
+	require.Equal(t, []*v2.ResourceType{userResourceType}, member.GrantableTo)
+}
+
+// Tests that the employees of a department are granted its membership and nobody else.
+func TestDepartmentBuilder_MemberGrants(t *testing.T) {
+	ctx := context.Background()
+
+	sales, err := parseIntoDepartmentResource(&client.Department{ZohoID: 858578000000277093, Department: "Sales"}, nil)
+	require.NoError(t, err)
+	testClient := test.NewTestClient(newEmployeesResponse(), nil)
+	d := newDepartmentBuilder(testClient, newEmployeeCache(testClient), "")
+	grants, _, _, err := d.Grants(ctx, sales, &pagination.Token{Size: 10})
+	require.NoError(t, err)
+	require.Empty(t, grants)
+
+	management, err := parseIntoDepartmentResource(&client.Department{ZohoID: 858578000000277092, Department: "Management"}, nil)
+	require.NoError(t, err)
+	testClient = test.NewTestClient(newEmployeesResponse(), nil)
+	d = newDepartmentBuilder(testClient, newEmployeeCache(testClient), "")
+	grants, _, _, err = d.Grants(ctx, management, &pagination.Token{Size: 10})
+	require.NoError(t, err)
+
+	var members []string
+	for _, g := range grants {
+		require.Equal(t, "department:858578000000277092:member", g.Entitlement.Id)
+		members = append(members, g.Principal.Id.Resource)
+	}
+	require.ElementsMatch(t, []string{"100000000000", "10000000001"}, members)
+}
+
+// Tests that departments are nested under their parent department, reading the departments once.
+func TestDepartmentBuilder_ListHierarchy(t *testing.T) {
+	ctx := context.Background()
+
+	requests := 0
+	testClient := test.NewMockClient(func(*http.Request) (*http.Response, error) {
+		requests++
+		return newDepartmentsResponse(), nil
+	})
+	d := newDepartmentBuilder(testClient, newEmployeeCache(testClient), "")
+	roots, _, _, err := d.List(ctx, nil, &pagination.Token{Size: 10})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(roots) != 1 || roots[0].DisplayName != "Management" {
+		t.Fatalf("Expected only the Management department at the top level, got %v", roots)
+	}
+	if roots[0].ParentResourceId != nil {
+		t.Errorf("Expected no parent for a top level department, got %v", roots[0].ParentResourceId)
+	}
+
+	children, _, _, err := d.List(ctx, roots[0].Id, &pagination.Token{Size: 10})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected the departments to be fetched once, got %d requests", requests)
+	}
+
+	if len(children) != 1 || children[0].DisplayName != "Sales" {
+		t.Fatalf("Expected the Sales department under Management, got %v", children)
+	}
+	if children[0].ParentResourceId.GetResource() != "858578000000277092" ||
+		children[0].ParentResourceId.GetResourceType() != departmentResourceType.Id {
+		t.Errorf("Unexpected parent resource id %v", children[0].ParentResourceId)
+	}
+
+	childType := &v2.ChildResourceType{}
+	for _, a := range children[0].Annotations {
+		if a.MessageIs(childType) {
+			return
+		}
+	}
+	t.Error("Expected departments to be annotated with the department child resource type")
+}
+
+// Tests that a department whose parent is not listed is synced at the top level with its subtree.
+func TestDepartmentBuilder_ListWithoutParent(t *testing.T) {
+	ctx := context.Background()
+
+	// The filters left out the parent of Sales.
+	testClient := formsClient(nil, []string{
+		`{"Zoho_ID":2,"Department":"Sales","Parent_Department.ID":"1"}`,
+		`{"Zoho_ID":3,"Department":"Inside Sales","Parent_Department.ID":"2"}`,
+	}, new([]string))
+	d := newDepartmentBuilder(testClient, newEmployeeCache(testClient), "")
+
+	roots, _, _, err := d.List(ctx, nil, &pagination.Token{Size: 10})
+	require.NoError(t, err)
+	require.Len(t, roots, 1)
+	require.Equal(t, "Sales", roots[0].DisplayName)
+	require.Nil(t, roots[0].ParentResourceId)
+
+	children, _, _, err := d.List(ctx, roots[0].Id, &pagination.Token{Size: 10})
+	require.NoError(t, err)
+	require.Len(t, children, 1)
+	require.Equal(t, "Inside Sales", children[0].DisplayName)
+}
+
+// Tests that a department filter on a nested department syncs that department.
+func TestDepartmentBuilder_ListFilteredChild(t *testing.T) {
+	ctx := context.Background()
+
+	// Zoho only returns the departments matching the search criteria.
+	var searches []string
+	testClient := formsClient(nil, []string{
+		`{"Zoho_ID":858578000000277093,"Department":"Sales","Parent_Department.ID":"858578000000277092"}`,
+	}, &searches)
+	employees := newEmployeeCache(testClient)
+	employees.scope = syncScope{departments: []client.SearchParam{{Field: "Department", Operator: "Is", Text: "Sales"}}}
+	d := newDepartmentBuilder(testClient, employees, "")
+
+	departments, _, _, err := d.List(ctx, nil, &pagination.Token{Size: 10})
+	require.NoError(t, err)
+	require.Len(t, departments, 1)
+	require.Equal(t, "858578000000277093", departments[0].Id.Resource)
+	for _, search := range searches {
+		require.Equal(t, "{searchField:'Department',searchOperator:'Is',searchText:'Sales'}", search)
+	}
+}
+
+// Tests that the department lead and the members of a department are granted.
+func TestDepartmentBuilder_Grants(t *testing.T) {
+	ctx := context.Background()
+
+	testClient := test.NewTestClient(newDepartmentsResponse(), nil)
+	d := newDepartmentBuilder(testClient, newEmployeeCache(testClient), "")
+	departments, _, _, err := d.List(ctx, nil, &pagination.Token{Size: 10})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	employeesResponse := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader(test.ReadFile("employeesMock.json"))),
+	}
+	employeesResponse.Header.Set("Content-Type", "application/json")
+
+	testClient = test.NewTestClient(employeesResponse, nil)
+	d = newDepartmentBuilder(testClient, newEmployeeCache(testClient), "")
+	grants, _, _, err := d.Grants(ctx, departments[0], &pagination.Token{Size: 10})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	granted := map[string][]string{}
+	for _, g := range grants {
+		slug := g.Entitlement.Id[strings.LastIndex(g.Entitlement.Id, ":")+1:]
+		granted[slug] = append(granted[slug], g.Principal.Id.Resource)
+	}
+
+	if len(granted[departmentLeadEntitlement]) != 1 || granted[departmentLeadEntitlement][0] != "100000000000" {
+		t.Errorf("Expected the lead grant for 100000000000, got %v", granted[departmentLeadEntitlement])
+	}
+	if len(granted[departmentMemberEntitlement]) != 2 {
+		t.Errorf("Expected 2 member grants, got %v", granted[departmentMemberEntitlement])
+	}
+
+	// The lead of Sales is David Johnson, who is not a member of the department.
+	testClient = test.NewTestClient(newDepartmentsResponse(), nil)
+	d = newDepartmentBuilder(testClient, newEmployeeCache(testClient), "")
+	children, _, _, err := d.List(ctx, departments[0].Id, &pagination.Token{Size: 10})
+	require.NoError(t, err)
+	require.Len(t, children, 1)
+
+	testClient = test.NewTestClient(newEmployeesResponse(), nil)
+	d = newDepartmentBuilder(testClient, newEmployeeCache(testClient), "")
+	grants, _, _, err = d.Grants(ctx, children[0], &pagination.Token{Size: 10})
+	require.NoError(t, err)
+	require.Len(t, grants, 1)
+	require.Equal(t, "department:858578000000277093:lead", grants[0].Entitlement.Id)
+	require.Equal(t, userResourceType.Id, grants[0].Principal.Id.ResourceType)
+	require.Equal(t, "10000000001", grants[0].Principal.Id.Resource)
+}
+
+func TestDepartmentBuilder_GrantRevoke(t *testing.T) {
+	ctx := context.Background()
+
+	const (
+		managementID = "858578000000277092"
+		salesID      = "858578000000277093"
+	)
+
+	user := &v2.Resource{Id: &v2.ResourceId{ResourceType: userResourceType.Id, Resource: "100000000000"}}
+	sales := &v2.Resource{Id: &v2.ResourceId{ResourceType: departmentResourceType.Id, Resource: salesID}}
+	member := entitlement.NewAssignmentEntitlement(sales, departmentMemberEntitlement)
+	memberGrant := newDepartmentMemberGrant(sales, user.Id.Resource)
+
+	employeeIn := func(departmentID string) string {
+		return fmt.Sprintf(`{"Zoho_ID":100000000000,"Department":"Management","Department.ID":%q}`, departmentID)
+	}
+	newBuilder := func(testClient *client.ZohoPeopleClient, fallbackDepartmentID string) *departmentBuilder {
+		return newDepartmentBuilder(testClient, newEmployeeCache(testClient), fallbackDepartmentID)
+	}
+
+	t.Run("grant replaces the membership", func(t *testing.T) {
+		var updates []url.Values
+		grants, _, err := newBuilder(recordingClient(employeeIn(managementID), "", &updates), managementID).Grant(ctx, user, member)
+		require.NoError(t, err)
+		require.Len(t, updates, 1)
+		require.JSONEq(t, `{"Department":"858578000000277093"}`, updates[0].Get("inputData"))
+
+		require.Len(t, grants, 1)
+		require.Equal(t, memberGrant.Id, grants[0].Id)
+
+		metadata := &v2.GrantMetadata{}
+		grantAnnotations := annotations.Annotations(grants[0].Annotations)
+		ok, err := grantAnnotations.Pick(metadata)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, managementID, metadata.Metadata.GetFields()["revoked_department_id"].GetStringValue())
+	})
+
+	t.Run("grant already a member", func(t *testing.T) {
+		var updates []url.Values
+		_, annos, err := newBuilder(recordingClient(employeeIn(salesID), "", &updates), managementID).Grant(ctx, user, member)
+		require.NoError(t, err)
+		require.Empty(t, updates)
+		require.True(t, annos.Contains(&v2.GrantAlreadyExists{}))
+	})
+
+	t.Run("revoke moves to the fallback department", func(t *testing.T) {
+		var updates []url.Values
+		_, err := newBuilder(recordingClient(employeeIn(salesID), "", &updates), managementID).Revoke(ctx, memberGrant)
+		require.NoError(t, err)
+		require.Len(t, updates, 1)
+		require.JSONEq(t, `{"Department":"858578000000277092"}`, updates[0].Get("inputData"))
+	})
+
+	t.Run("revoke without fallback department", func(t *testing.T) {
+		var updates []url.Values
+		_, err := newBuilder(recordingClient(employeeIn(salesID), "", &updates), "").Revoke(ctx, memberGrant)
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+		require.Empty(t, updates)
+	})
+
+	t.Run("lead cannot be granted", func(t *testing.T) {
+		var updates []url.Values
+		lead := entitlement.NewPermissionEntitlement(sales, departmentLeadEntitlement)
+		_, _, err := newBuilder(recordingClient(employeeIn(managementID), "", &updates), managementID).Grant(ctx, user, lead)
+		require.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+}
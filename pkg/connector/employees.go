@@ -0,0 +1,269 @@
+package connector
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-zoho-people/pkg/client"
+)
+
+// employeeCache indexes the employees read during a sync. Users, roles, department members and
+// role assignments are all read from the employee form, so the user listing records the role and
+// department of every employee as it pages through Zoho and the other builders read the index.
+// That keeps a full sync at roughly one API call per page of employees. The index only holds IDs,
+// the employee records are released with each page. The department hierarchy is read once per
+// sync as well.
+type employeeCache struct {
+	client *client.ZohoPeopleClient
+	scope  syncScope
+
+	mu sync.Mutex
+	// index is complete once the user listing reached its last page.
+	index *employeeIndex
+	// partial is filled by the user listing, nextPageToken is the page it expects next.
+	partial       *employeeIndex
+	nextPageToken string
+	// departments maps a parent department ID to the departments nested directly under it, the top
+	// level departments and those whose parent is not synced are kept under "".
+	departments map[string][]client.Department
+	// scanMu makes concurrent readers wait for a single scan of the employees.
+	scanMu sync.Mutex
+}
+
+// syncScope restricts the sync to the employees and departments matching the Zoho search criteria.
+type syncScope struct {
+	employees   []client.SearchParam
+	departments []client.SearchParam
+}
+
+// employeeIndex holds the IDs of the employees in the sync scope by role and department.
+type employeeIndex struct {
+	inScope           map[string]struct{}
+	roles             map[string]indexedRole
+	roleMembers       map[string][]string
+	departmentMembers map[string][]string
+}
+
+func newEmployeeIndex() *employeeIndex {
+	return &employeeIndex{
+		inScope:           make(map[string]struct{}),
+		roles:             make(map[string]indexedRole),
+		roleMembers:       make(map[string][]string),
+		departmentMembers: make(map[string][]string),
+	}
+}
+
+func (i *employeeIndex) add(employees []client.Employee) {
+	for _, employee := range employees {
+		id := employee.ZohoID.String()
+		i.inScope[id] = struct{}{}
+		if employee.RoleID != "" {
+			i.roles[employee.RoleID] = indexedRole{id: employee.RoleID, name: employee.Role}
+			i.roleMembers[employee.RoleID] = append(i.roleMembers[employee.RoleID], id)
+		}
+		if employee.DepartmentID != "" {
+			i.departmentMembers[employee.DepartmentID] = append(i.departmentMembers[employee.DepartmentID], id)
+		}
+	}
+}
+
+// indexedRole is a role held by at least one employee.
+type indexedRole struct {
+	id   string
+	name string
+}
+
+// Roles returns the roles held by the employees, ordered by role ID.
+func (i *employeeIndex) Roles() []indexedRole {
+	roles := make([]indexedRole, 0, len(i.roles))
+	for _, role := range i.roles {
+		roles = append(roles, role)
+	}
+	slices.SortFunc(roles, func(a, b indexedRole) int {
+		return cmp.Compare(a.id, b.id)
+	})
+	return roles
+}
+
+// RoleMembers returns the IDs of the employees holding the role.
+func (i *employeeIndex) RoleMembers(roleID string) []string {
+	return i.roleMembers[roleID]
+}
+
+// DepartmentMembers returns the IDs of the employees assigned to the department.
+func (i *employeeIndex) DepartmentMembers(departmentID string) []string {
+	return i.departmentMembers[departmentID]
+}
+
+func newEmployeeCache(c *client.ZohoPeopleClient) *employeeCache {
+	return &employeeCache{
+		client: c,
+	}
+}
+
+// ListUsers returns a page of employees from Zoho and adds it to the index of the sync.
+func (e *employeeCache) ListUsers(ctx context.Context, options client.PageOptions) ([]client.Employee, string, annotations.Annotations, error) {
+	options.Search = e.scope.employees
+
+	employees, nextPageToken, annos, err := e.client.ListUsers(ctx, options)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	switch {
+	case options.PageToken == "":
+		e.partial = newEmployeeIndex()
+	case e.partial == nil || options.PageToken != e.nextPageToken:
+		// A sync resumed by another process, the index is built with a scan when needed.
+		e.partial = nil
+		return employees, nextPageToken, annos, nil
+	}
+
+	e.partial.add(employees)
+	e.nextPageToken = nextPageToken
+	if nextPageToken == "" {
+		e.index = e.partial
+		e.partial = nil
+	}
+
+	return employees, nextPageToken, annos, nil
+}
+
+// ChildDepartments returns the departments nested directly under the parent department, or the
+// top level departments when parentID is empty. The departments are read once per sync.
+func (e *employeeCache) ChildDepartments(ctx context.Context, parentID string) ([]client.Department, error) {
+	e.scanMu.Lock()
+	defer e.scanMu.Unlock()
+
+	e.mu.Lock()
+	departments := e.departments
+	e.mu.Unlock()
+	if departments != nil {
+		return departments[parentID], nil
+	}
+
+	var all []client.Department
+	listed := make(map[string]struct{})
+	options := client.PageOptions{
+		PageSize: client.ItemsPerPage,
+		Search:   e.scope.departments,
+	}
+	for department, err := range client.Records(ctx, e.client.ListDepartments, options) {
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, department)
+		listed[department.ZohoID.String()] = struct{}{}
+	}
+
+	// A department whose parent is not listed, e.g. because the filters leave the parent out, is
+	// kept at the top level so it and its subtree are still synced.
+	departments = make(map[string][]client.Department)
+	for _, department := range all {
+		parentID := department.ParentDepartmentID
+		if _, ok := listed[parentID]; !ok {
+			parentID = ""
+		}
+		departments[parentID] = append(departments[parentID], department)
+	}
+
+	e.mu.Lock()
+	e.departments = departments
+	e.mu.Unlock()
+
+	return departments[parentID], nil
+}
+
+// Index returns the employee index of the sync. When the user listing did not run in this
+// process, the employees are read once to build it.
+func (e *employeeCache) Index(ctx context.Context) (*employeeIndex, error) {
+	e.scanMu.Lock()
+	defer e.scanMu.Unlock()
+
+	e.mu.Lock()
+	index := e.index
+	e.mu.Unlock()
+	if index != nil {
+		return index, nil
+	}
+
+	index = newEmployeeIndex()
+	options := client.PageOptions{
+		PageSize: client.ItemsPerPage,
+		Search:   e.scope.employees,
+	}
+	for employee, err := range client.Records(ctx, e.client.ListUsers, options) {
+		if err != nil {
+			return nil, err
+		}
+		index.add([]client.Employee{employee})
+	}
+
+	e.mu.Lock()
+	e.index = index
+	e.mu.Unlock()
+
+	return index, nil
+}
+
+// InScope reports whether the employee is synced. Grants that reference other employees, such as
+// the manager or the department lead, must not point at employees outside of the sync scope.
+func (e *employeeCache) InScope(ctx context.Context, employeeID string) (bool, error) {
+	if len(e.scope.employees) == 0 {
+		return true, nil
+	}
+
+	index, err := e.Index(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := index.inScope[employeeID]
+	return ok, nil
+}
+
+// Reset drops the indexes, so the next sync reads the current employee and department data.
+func (e *employeeCache) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.index = nil
+	e.partial = nil
+	e.nextPageToken = ""
+	e.departments = nil
+}
+
+// pageOf returns a page of the indexed items. Page tokens are offsets into the items.
+func pageOf[T any](items []T, pageToken string, pageSize int) ([]T, string, error) {
+	offset := 0
+	if pageToken != "" {
+		var err error
+		offset, err = strconv.Atoi(pageToken)
+		if err != nil || offset < 0 {
+			return nil, "", fmt.Errorf("baton-zoho-people: invalid page token %q", pageToken)
+		}
+	}
+
+	if pageSize <= 0 || pageSize > client.ItemsPerPage {
+		pageSize = client.ItemsPerPage
+	}
+	if offset >= len(items) {
+		return nil, "", nil
+	}
+
+	end := min(offset+pageSize, len(items))
+	nextPageToken := ""
+	if end < len(items) {
+		nextPageToken = strconv.Itoa(end)
+	}
+
+	return items[offset:end], nextPageToken, nil
+}
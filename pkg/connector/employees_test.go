@@ -0,0 +1,78 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-zoho-people/pkg/client"
+	"github.com/conductorone/baton-zoho-people/test"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests that the user listing fills the employee index the grants are paged from.
+func TestEmployeeCache_IndexFromUserListing(t *testing.T) {
+	ctx := context.Background()
+
+	requests := 0
+	testClient := test.NewMockClient(func(*http.Request) (*http.Response, error) {
+		requests++
+		return newEmployeesResponse(), nil
+	})
+	employees := newEmployeeCache(testClient)
+
+	users, _, _, err := newUserBuilder(testClient, employees, nil, offboarding{}).List(ctx, nil, &pagination.Token{Size: 10})
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	require.Equal(t, 1, requests)
+
+	department, err := parseIntoDepartmentResource(&client.Department{ZohoID: 858578000000277092, Department: "Management"}, nil)
+	require.NoError(t, err)
+	d := newDepartmentBuilder(testClient, employees, "")
+
+	var members []string
+	pToken := &pagination.Token{Size: 1}
+	for {
+		grants, nextPageToken, annos, err := d.Grants(ctx, department, pToken)
+		require.NoError(t, err)
+		require.Empty(t, annos)
+		for _, g := range grants {
+			if g.Entitlement.Id == entitlement.NewEntitlementID(department, departmentMemberEntitlement) {
+				members = append(members, g.Principal.Id.Resource)
+			}
+		}
+		if nextPageToken == "" {
+			break
+		}
+		pToken = &pagination.Token{Size: 1, Token: nextPageToken}
+	}
+	require.Len(t, members, 2)
+	require.Equal(t, 1, requests)
+}
+
+// Tests that the employee index is built with a scan when the user listing did not run, and dropped on reset.
+func TestEmployeeCache_IndexScan(t *testing.T) {
+	ctx := context.Background()
+
+	requests := 0
+	testClient := test.NewMockClient(func(*http.Request) (*http.Response, error) {
+		requests++
+		return newEmployeesResponse(), nil
+	})
+	employees := newEmployeeCache(testClient)
+
+	index, err := employees.Index(ctx)
+	require.NoError(t, err)
+	require.Len(t, index.DepartmentMembers("858578000000277092"), 2)
+	require.Len(t, index.RoleMembers("858578000000035639"), 1)
+	require.Equal(t, 1, requests)
+
+	_, err = employees.Index(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, requests)
+
+	employees.Reset()
+	require.Nil(t, employees.index)
+}
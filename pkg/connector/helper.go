@@ -1,12 +1,29 @@
 package connector
 
 import (
+	"context"
 	"strconv"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-zoho-people/pkg/client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// getEmployee reads the current employee record, bypassing the employee index of the sync.
+func getEmployee(ctx context.Context, c *client.ZohoPeopleClient, userID string) (*client.Employee, error) {
+	employees, _, _, err := c.GetEmployeeByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(employees) == 0 {
+		return nil, status.Errorf(codes.NotFound, "baton-zoho-people: employee %s not found", userID)
+	}
+
+	return &employees[0], nil
+}
+
 func getToken(pToken *pagination.Token, resourceType *v2.ResourceType) (*pagination.Bag, string, error) {
 	var pageToken string
 	_, bag, err := unmarshalSkipToken(pToken)
@@ -37,8 +37,8 @@ func initClient(t *testing.T) *client.ZohoPeopleClient {
 	}
 	c, err := client.New(
 		ctx,
-		client.ZohoAuthData{},
-		oauth2.StaticTokenSource(&token),
+		client.ZohoAuthData{DomainAccount: domainAccount},
+		client.WithTokenSource(oauth2.StaticTokenSource(&token)),
 	)
 	if err != nil {
 		t.Errorf("ERROR: Failed to create client: %v", err)
@@ -49,7 +49,7 @@ func initClient(t *testing.T) *client.ZohoPeopleClient {
 func TestUserBuilderList(t *testing.T) {
 	c := initClient(t)
 
-	u := newUserBuilder(c)
+	u := newUserBuilder(c, newEmployeeCache(c), nil, offboarding{})
 	res, _, _, err := u.List(ctx, parentResourceID, pToken)
 	assert.Nil(t, err)
 	assert.NotNil(t, res)
@@ -61,7 +61,7 @@ func TestUserBuilderList(t *testing.T) {
 func TestRoleBuilderList(t *testing.T) {
 	c := initClient(t)
 
-	r := newRoleBuilder(c)
+	r := newRoleBuilder(c, newEmployeeCache(c), "")
 
 	res, _, _, err := r.List(ctx, parentResourceID, pToken)
 	assert.Nil(t, err)
@@ -70,3 +70,16 @@ func TestRoleBuilderList(t *testing.T) {
 	message := fmt.Sprintf("Amount of roles obtained: %d", len(res))
 	t.Log(message)
 }
+
+func TestDepartmentBuilderList(t *testing.T) {
+	c := initClient(t)
+
+	d := newDepartmentBuilder(c, newEmployeeCache(c), "")
+
+	res, _, _, err := d.List(ctx, parentResourceID, pToken)
+	assert.Nil(t, err)
+	assert.NotNil(t, res)
+
+	message := fmt.Sprintf("Amount of departments obtained: %d", len(res))
+	t.Log(message)
+}
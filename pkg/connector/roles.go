@@ -9,26 +9,65 @@ import (
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
 	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
 	resourceType "github.com/conductorone/baton-sdk/pkg/types/resource"
 	"github.com/conductorone/baton-zoho-people/pkg/client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+const roleAssignedEntitlement = "assigned"
+
 type roleBuilder struct {
 	resourceType *v2.ResourceType
 	client       *client.ZohoPeopleClient
+	employees    *employeeCache
+	// defaultRoleID is the role an employee falls back to when their role is revoked.
+	defaultRoleID string
 }
 
-var zohoRoles = []string{"Admin", "Team Incharge", "Team member", "Manager", "Director"}
+// builtInRoles are the roles every Zoho People organization starts with. They cannot be renamed
+// or deleted in Zoho, so they are flagged as immutable.
+var builtInRoles = map[string]struct{}{
+	"admin":         {},
+	"team incharge": {},
+	"team member":   {},
+	"manager":       {},
+	"director":      {},
+}
 
 func (o *roleBuilder) ResourceType(_ context.Context) *v2.ResourceType {
 	return roleResourceType
 }
 
-func (o *roleBuilder) List(_ context.Context, _ *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+// List discovers the roles from the Role and Role.ID fields of the employees, which also covers
+// the custom roles of the organization. Zoho People has no API to list the roles themselves, so a
+// role nobody holds is not synced.
+func (o *roleBuilder) List(ctx context.Context, _ *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
 	var resources []*v2.Resource
 
-	for _, zohoRole := range zohoRoles {
-		roleResource, err := parseIntoRoleResource(zohoRole)
+	bag, pageToken, err := getToken(pToken, roleResourceType)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	index, err := o.employees.Index(ctx)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	roles, nextPageToken, err := pageOf(index.Roles(), pageToken, pToken.Size)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	err = bag.Next(nextPageToken)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	for _, role := range roles {
+		roleResource, err := parseIntoRoleResource(role.id, role.name)
 		if err != nil {
 			return nil, "", nil, err
 		}
@@ -36,7 +75,12 @@ func (o *roleBuilder) List(_ context.Context, _ *v2.ResourceId, _ *pagination.To
 		resources = append(resources, roleResource)
 	}
 
-	return resources, "", nil, nil
+	nextPageToken, err = bag.Marshal()
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return resources, nextPageToken, nil, nil
 }
 
 func (o *roleBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
@@ -48,18 +92,131 @@ func (o *roleBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *
 		entitlement.WithDisplayName(fmt.Sprintf("assigned role %s", resource.DisplayName)),
 	}
 
-	entitlements = append(entitlements, entitlement.NewPermissionEntitlement(resource, "assigned", assigmentOptions...))
+	entitlements = append(entitlements, entitlement.NewPermissionEntitlement(resource, roleAssignedEntitlement, assigmentOptions...))
 
 	return entitlements, "", nil, nil
 }
 
-func (o *roleBuilder) Grants(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
-	return nil, "", nil, nil
+// Grants returns an assignment grant for every employee holding the role. Zoho keeps the role on
+// the employee record, so the holders come from the employee index of the sync.
+func (o *roleBuilder) Grants(ctx context.Context, res *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	var grants []*v2.Grant
+
+	bag, pageToken, err := getToken(pToken, roleResourceType)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	index, err := o.employees.Index(ctx)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	roleID := res.Id.Resource
+	userIDs, nextPageToken, err := pageOf(index.RoleMembers(roleID), pageToken, pToken.Size)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	err = bag.Next(nextPageToken)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	for _, userID := range userIDs {
+		grants = append(grants, grant.NewGrant(
+			res,
+			roleAssignedEntitlement,
+			&v2.ResourceId{
+				ResourceType: userResourceType.Id,
+				Resource:     userID,
+			},
+			grant.WithAnnotation(&v2.V1Identifier{
+				Id: fmt.Sprintf("role-grant:%s:%s:%s", roleID, userID, roleAssignedEntitlement),
+			}),
+		))
+	}
+
+	nextPageToken, err = bag.Marshal()
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return grants, nextPageToken, nil, nil
+}
+
+// Grant assigns the role to the employee. Zoho People allows a single role per employee, so the
+// role replaces the one the employee currently holds.
+func (o *roleBuilder) Grant(ctx context.Context, principal *v2.Resource, ent *v2.Entitlement) (annotations.Annotations, error) {
+	if principal.Id.ResourceType != userResourceType.Id {
+		return nil, status.Errorf(codes.InvalidArgument, "baton-zoho-people: roles can only be granted to users, got %s", principal.Id.ResourceType)
+	}
+
+	userID := principal.Id.Resource
+	roleID := ent.Resource.Id.Resource
+
+	currentRoleID, err := o.getEmployeeRoleID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if currentRoleID == roleID {
+		return annotations.New(&v2.GrantAlreadyExists{}), nil
+	}
+
+	annos, err := o.client.UpdateEmployee(ctx, userID, map[string]string{"Role": roleID})
+	if err != nil {
+		return nil, err
+	}
+	o.employees.Reset()
+
+	return annos, nil
+}
+
+// Revoke moves the employee back to the configured default role, since every employee needs a role.
+func (o *roleBuilder) Revoke(ctx context.Context, g *v2.Grant) (annotations.Annotations, error) {
+	userID := g.Principal.Id.Resource
+	roleID := g.Entitlement.Resource.Id.Resource
+
+	if o.defaultRoleID == "" {
+		return nil, status.Error(codes.FailedPrecondition, "baton-zoho-people: a default role is required to revoke roles")
+	}
+	if roleID == o.defaultRoleID {
+		return nil, status.Errorf(codes.FailedPrecondition, "baton-zoho-people: the default role %s cannot be revoked", roleID)
+	}
+
+	currentRoleID, err := o.getEmployeeRoleID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if currentRoleID != roleID {
+		return annotations.New(&v2.GrantAlreadyRevoked{}), nil
+	}
+
+	annos, err := o.client.UpdateEmployee(ctx, userID, map[string]string{"Role": o.defaultRoleID})
+	if err != nil {
+		return nil, err
+	}
+	o.employees.Reset()
+
+	return annos, nil
+}
+
+func (o *roleBuilder) getEmployeeRoleID(ctx context.Context, userID string) (string, error) {
+	employee, err := getEmployee(ctx, o.client, userID)
+	if err != nil {
+		return "", err
+	}
+
+	return employee.RoleID, nil
 }
 
-func parseIntoRoleResource(zohoRole string) (*v2.Resource, error) {
+func parseIntoRoleResource(roleID, roleName string) (*v2.Resource, error) {
+	_, builtIn := builtInRoles[strings.ToLower(roleName)]
+
 	profile := map[string]interface{}{
-		"role_name": zohoRole,
+		"role_id":   roleID,
+		"role_name": roleName,
+		"immutable": builtIn,
 	}
 
 	roleTraits := []resourceType.RoleTraitOption{
@@ -67,9 +224,9 @@ func parseIntoRoleResource(zohoRole string) (*v2.Resource, error) {
 	}
 
 	ret, err := resourceType.NewRoleResource(
-		zohoRole,
+		roleName,
 		roleResourceType,
-		GetRoleID(zohoRole),
+		roleID,
 		roleTraits,
 	)
 	if err != nil {
@@ -79,13 +236,11 @@ func parseIntoRoleResource(zohoRole string) (*v2.Resource, error) {
 	return ret, nil
 }
 
-func newRoleBuilder(c *client.ZohoPeopleClient) *roleBuilder {
+func newRoleBuilder(c *client.ZohoPeopleClient, employees *employeeCache, defaultRoleID string) *roleBuilder {
 	return &roleBuilder{
-		resourceType: roleResourceType,
-		client:       c,
+		resourceType:  roleResourceType,
+		client:        c,
+		employees:     employees,
+		defaultRoleID: defaultRoleID,
 	}
 }
-
-func GetRoleID(roleName string) string {
-	return fmt.Sprintf("zoho-role_%s", strings.ToLower(strings.ReplaceAll(roleName, " ", "-")))
-}
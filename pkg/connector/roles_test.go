@@ -0,0 +1,204 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	"github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-zoho-people/pkg/client"
+	"github.com/conductorone/baton-zoho-people/test"
+	"github.com/conductorone/baton-zoho-people/test/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newEmployeesResponse() *http.Response {
+	mockResponse := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader(test.ReadFile("employeesMock.json"))),
+	}
+	mockResponse.Header.Set("Content-Type", "application/json")
+	return mockResponse
+}
+
+// Tests that roles are discovered from the employees and keyed by their Zoho role ID.
+func TestRoleBuilder_List(t *testing.T) {
+	ctx := context.Background()
+
+	testClient := test.NewTestClient(newEmployeesResponse(), nil)
+	r := newRoleBuilder(testClient, newEmployeeCache(testClient), "")
+	roles, _, _, err := r.List(ctx, nil, &pagination.Token{Size: 10})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := map[string]string{
+		"858578000000035647": "Team Incharge",
+		"858578000000035639": "Manager",
+	}
+	if len(roles) != len(expected) {
+		t.Fatalf("Expected %d roles, got %d", len(expected), len(roles))
+	}
+
+	for _, role := range roles {
+		name, ok := expected[role.Id.Resource]
+		if !ok || role.DisplayName != name {
+			t.Errorf("Unexpected role %s %q", role.Id.Resource, role.DisplayName)
+		}
+
+		roleTrait, err := resource.GetRoleTrait(role)
+		if err != nil {
+			t.Fatalf("Expected a role trait, got %v", err)
+		}
+		if !roleTrait.Profile.GetFields()["immutable"].GetBoolValue() {
+			t.Errorf("Expected built-in role %q to be immutable", role.DisplayName)
+		}
+	}
+}
+
+// Tests that roles are paged without repeating a role and without fetching the employees again.
+func TestRoleBuilder_ListPages(t *testing.T) {
+	ctx := context.Background()
+
+	requests := 0
+	testClient := test.NewMockClient(func(*http.Request) (*http.Response, error) {
+		requests++
+		return newEmployeesResponse(), nil
+	})
+	r := newRoleBuilder(testClient, newEmployeeCache(testClient), "")
+
+	var roleIDs []string
+	pToken := &pagination.Token{Size: 1}
+	for {
+		roles, nextPageToken, _, err := r.List(ctx, nil, pToken)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		roleIDs = append(roleIDs, roles[0].Id.Resource)
+		if nextPageToken == "" {
+			break
+		}
+		pToken = &pagination.Token{Size: 1, Token: nextPageToken}
+	}
+	require.Equal(t, []string{"858578000000035639", "858578000000035647"}, roleIDs)
+	require.Equal(t, 1, requests)
+}
+
+// Tests that role assignments are granted from the employee index without further requests.
+func TestRoleBuilder_Grants(t *testing.T) {
+	ctx := context.Background()
+
+	requests := 0
+	testClient := test.NewMockClient(func(*http.Request) (*http.Response, error) {
+		requests++
+		return newEmployeesResponse(), nil
+	})
+
+	employees := newEmployeeCache(testClient)
+	r := newRoleBuilder(testClient, employees, "")
+	roles, _, _, err := r.List(ctx, nil, &pagination.Token{Size: 10})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, role := range roles {
+		grants, _, _, err := r.Grants(ctx, role, &pagination.Token{Size: 10})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(grants) != 1 {
+			t.Errorf("Expected one grant for role %q, got %d", role.DisplayName, len(grants))
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("Expected the employees to be fetched once, got %d requests", requests)
+	}
+}
+
+// recordingClient answers getDataByID with the employee record and records the query of every
+// update. writeResponse replaces the default updateRecord response when set.
+func recordingClient(record, writeResponse string, updates *[]url.Values) *client.ZohoPeopleClient {
+	return test.NewMockClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodPost {
+			return mock.JSONResponse(fmt.Sprintf(`{"response":{"result":[%s],"status":0}}`, record)), nil
+		}
+		*updates = append(*updates, req.URL.Query())
+		if writeResponse != "" {
+			return mock.JSONResponse(writeResponse), nil
+		}
+		return mock.JSONResponse(`{"response":{"result":{"message":"Successfully Updated"},"message":"Data updated successfully","status":0}}`), nil
+	})
+}
+
+func employeeWithRole(roleID string) string {
+	return fmt.Sprintf(`{"Zoho_ID":100000000000,"Role.ID":%q}`, roleID)
+}
+
+func TestRoleBuilder_GrantRevoke(t *testing.T) {
+	ctx := context.Background()
+
+	const (
+		defaultRoleID = "858578000000035645"
+		managerRoleID = "858578000000035639"
+	)
+
+	user := &v2.Resource{Id: &v2.ResourceId{ResourceType: userResourceType.Id, Resource: "100000000000"}}
+	role := &v2.Resource{Id: &v2.ResourceId{ResourceType: roleResourceType.Id, Resource: managerRoleID}}
+	assigned := entitlement.NewPermissionEntitlement(role, roleAssignedEntitlement)
+	roleGrant := grant.NewGrant(role, roleAssignedEntitlement, user.Id)
+
+	newBuilder := func(testClient *client.ZohoPeopleClient, defaultRoleID string) *roleBuilder {
+		return newRoleBuilder(testClient, newEmployeeCache(testClient), defaultRoleID)
+	}
+
+	t.Run("grant", func(t *testing.T) {
+		var updates []url.Values
+		_, err := newBuilder(recordingClient(employeeWithRole(defaultRoleID), "", &updates), defaultRoleID).Grant(ctx, user, assigned)
+		require.NoError(t, err)
+		require.Len(t, updates, 1)
+		require.Equal(t, "100000000000", updates[0].Get("recordId"))
+		require.JSONEq(t, `{"Role":"858578000000035639"}`, updates[0].Get("inputData"))
+	})
+
+	t.Run("grant already held", func(t *testing.T) {
+		var updates []url.Values
+		annos, err := newBuilder(recordingClient(employeeWithRole(managerRoleID), "", &updates), defaultRoleID).Grant(ctx, user, assigned)
+		require.NoError(t, err)
+		require.Empty(t, updates)
+		require.True(t, annos.Contains(&v2.GrantAlreadyExists{}))
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		var updates []url.Values
+		_, err := newBuilder(recordingClient(employeeWithRole(managerRoleID), "", &updates), defaultRoleID).Revoke(ctx, roleGrant)
+		require.NoError(t, err)
+		require.Len(t, updates, 1)
+		require.JSONEq(t, `{"Role":"858578000000035645"}`, updates[0].Get("inputData"))
+	})
+
+	t.Run("revoke already revoked", func(t *testing.T) {
+		var updates []url.Values
+		annos, err := newBuilder(recordingClient(employeeWithRole(defaultRoleID), "", &updates), defaultRoleID).Revoke(ctx, roleGrant)
+		require.NoError(t, err)
+		require.Empty(t, updates)
+		require.True(t, annos.Contains(&v2.GrantAlreadyRevoked{}))
+	})
+
+	t.Run("revoke without default role", func(t *testing.T) {
+		var updates []url.Values
+		_, err := newBuilder(recordingClient(employeeWithRole(managerRoleID), "", &updates), "").Revoke(ctx, roleGrant)
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+		require.Empty(t, updates)
+	})
+}
@@ -0,0 +1,129 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/conductorone/baton-zoho-people/pkg/client"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// systemFields are added by Zoho to the records of every form and are not listed as form fields.
+var systemFields = []string{
+	"Zoho_ID",
+	"AddedBy",
+	"AddedTime",
+	"ModifiedBy",
+	"ModifiedTime",
+	"CreatedTime",
+	"ApprovalStatus",
+}
+
+// formFields holds the field labels of a form.
+type formFields map[string]struct{}
+
+// has reports whether the label is a field of the form. Derived labels such as "Role.ID" or
+// "Photo_downloadUrl" are accepted when the field they are derived from exists.
+func (f formFields) has(label string) bool {
+	if slices.Contains(systemFields, label) {
+		return true
+	}
+
+	if _, ok := f[label]; ok {
+		return true
+	}
+
+	base, _, found := strings.Cut(label, ".")
+	if !found {
+		base, _, found = strings.Cut(label, "_downloadUrl")
+	}
+	if !found {
+		return false
+	}
+	_, ok := f[base]
+	return ok
+}
+
+func getFormFields(ctx context.Context, c *client.ZohoPeopleClient, formLinkName string) (formFields, error) {
+	components, _, err := c.ListFormComponents(ctx, formLinkName)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(formFields, len(components))
+	for _, component := range components {
+		fields[component.LabelName] = struct{}{}
+	}
+
+	return fields, nil
+}
+
+// configuredLabel is a field label the configuration refers to and the option it comes from.
+type configuredLabel struct {
+	label string
+	usage string
+}
+
+// validateSchema checks the profile field mapping and the sync scope against the fields of the
+// Employee and Department forms of the organization, so a renamed or missing field fails the
+// connector at startup instead of silently dropping data. Each form is checked on its own: when
+// the fields of a form cannot be read, its labels are not checked and a warning is logged, the
+// sync itself does not depend on the forms metadata.
+func validateSchema(ctx context.Context, c *client.ZohoPeopleClient, profileFields map[string]string, scope syncScope) error {
+	var employeeLabels, departmentLabels []configuredLabel
+	for _, label := range sortedKeys(profileFields) {
+		employeeLabels = append(employeeLabels, configuredLabel{label: label, usage: "profile field"})
+	}
+	for _, filter := range scope.employees {
+		employeeLabels = append(employeeLabels, configuredLabel{label: filter.Field, usage: "employee filter field"})
+	}
+	for _, filter := range scope.departments {
+		departmentLabels = append(departmentLabels, configuredLabel{label: filter.Field, usage: "department filter field"})
+	}
+
+	var problems []string
+	for _, form := range []struct {
+		linkName string
+		labels   []configuredLabel
+	}{
+		{linkName: client.EmployeeForm, labels: employeeLabels},
+		{linkName: client.DepartmentForm, labels: departmentLabels},
+	} {
+		if len(form.labels) == 0 {
+			continue
+		}
+
+		fields, err := getFormFields(ctx, c, form.linkName)
+		if err != nil {
+			ctxzap.Extract(ctx).Warn("unable to validate the configuration against the Zoho form",
+				zap.String("form", form.linkName), zap.Error(err))
+			continue
+		}
+
+		for _, label := range form.labels {
+			if !fields.has(label.label) {
+				problems = append(problems, fmt.Sprintf("%s %q is not a field of the %s form", label.usage, label.label, form.linkName))
+			}
+		}
+	}
+
+	if len(problems) != 0 {
+		return status.Errorf(codes.InvalidArgument, "baton-zoho-people: %s, run the describe command to list the fields", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,71 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/conductorone/baton-zoho-people/pkg/client"
+	"github.com/conductorone/baton-zoho-people/test"
+	"github.com/conductorone/baton-zoho-people/test/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// componentsClient answers the form components endpoint with the fields of the form.
+func componentsClient(forms map[string]string) *client.ZohoPeopleClient {
+	return test.NewMockClient(func(req *http.Request) (*http.Response, error) {
+		for form, components := range forms {
+			if strings.HasSuffix(req.URL.Path, "/"+form+"/components") {
+				return mock.JSONResponse(`{"response":{"result":` + components + `,"status":0}}`), nil
+			}
+		}
+		return mock.JSONResponse(`{"response":{"errors":{"code":7011,"message":"Invalid form name"},"status":1}}`), nil
+	})
+}
+
+func TestValidateSchema(t *testing.T) {
+	ctx := context.Background()
+
+	testClient := componentsClient(map[string]string{
+		client.EmployeeForm: `[
+			{"comptype":"Lookup","displayname":"Role","labelname":"Role"},
+			{"comptype":"Picklist","displayname":"Employee Status","labelname":"Employeestatus"},
+			{"comptype":"Text","displayname":"Cost Center","labelname":"Cost_Center"}
+		]`,
+	})
+
+	activeOnly := syncScope{employees: []client.SearchParam{{Field: "Employeestatus", Operator: "Is", Text: "Active"}}}
+	err := validateSchema(ctx, testClient, map[string]string{
+		"Cost_Center":  "cost_center",
+		"Role.ID":      "role",
+		"ModifiedTime": "modified_time",
+	}, activeOnly)
+	require.NoError(t, err)
+
+	err = validateSchema(ctx, testClient, map[string]string{"CostCenter": "cost_center"}, syncScope{
+		employees: []client.SearchParam{{Field: "Status", Operator: "Is", Text: "Active"}},
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.ErrorContains(t, err, `profile field "CostCenter"`)
+	require.ErrorContains(t, err, `employee filter field "Status"`)
+
+	// The department form cannot be read, the check is skipped.
+	err = validateSchema(ctx, testClient, nil, syncScope{
+		departments: []client.SearchParam{{Field: "Location", Operator: "Is", Text: "Berlin"}},
+	})
+	require.NoError(t, err)
+
+	// A form that cannot be read does not skip the checks of the other one.
+	testClient = componentsClient(map[string]string{
+		client.DepartmentForm: `[{"comptype":"Text","displayname":"Department","labelname":"Department"}]`,
+	})
+	err = validateSchema(ctx, testClient, map[string]string{"Cost_Center": "cost_center"}, syncScope{
+		departments: []client.SearchParam{{Field: "Location", Operator: "Is", Text: "Berlin"}},
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.ErrorContains(t, err, `department filter field "Location"`)
+	require.NotContains(t, err.Error(), "Cost_Center")
+}
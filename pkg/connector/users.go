@@ -1,21 +1,48 @@
 package connector
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
 	"github.com/conductorone/baton-sdk/pkg/types/grant"
 	"github.com/conductorone/baton-sdk/pkg/types/resource"
 	"github.com/conductorone/baton-zoho-people/pkg/client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+const userManagerEntitlement = "manager"
+
+// defaultOffboardStatus is the employee status set when an employee is offboarded.
+const defaultOffboardStatus = "Terminated"
+
+// offboarding configures how Delete offboards an employee.
+type offboarding struct {
+	// status is the Employeestatus value set on the employee, e.g. Resigned or Terminated.
+	status string
+	// roleID replaces the role of the employee when set.
+	roleID string
+	// clearManager removes the reporting manager of the employee.
+	clearManager bool
+}
+
 type userBuilder struct {
-	resourceType *v2.ResourceType
-	client       *client.ZohoPeopleClient
+	resourceType  *v2.ResourceType
+	client        *client.ZohoPeopleClient
+	employees     *employeeCache
+	profileFields map[string]string
+	offboarding   offboarding
 }
 
 func (o *userBuilder) ResourceType(_ context.Context) *v2.ResourceType {
@@ -31,7 +58,12 @@ func (o *userBuilder) List(ctx context.Context, _ *v2.ResourceId, pToken *pagina
 	if err != nil {
 		return nil, "", nil, err
 	}
-	employees, nextPageToken, _, err := o.client.ListUsers(ctx, client.PageOptions{
+	// A new user listing starts a new sync, drop the employee index of the previous one.
+	if pageToken == "" {
+		o.employees.Reset()
+	}
+
+	employees, nextPageToken, _, err := o.employees.ListUsers(ctx, client.PageOptions{
 		PageSize:  pToken.Size,
 		PageToken: pageToken,
 	})
@@ -47,7 +79,7 @@ func (o *userBuilder) List(ctx context.Context, _ *v2.ResourceId, pToken *pagina
 
 	for _, employee := range employees {
 		employeeCopy := employee
-		userResource, err := parseIntoUserResource(&employeeCopy, "")
+		userResource, err := parseIntoUserResource(&employeeCopy, "", o.profileFields)
 		if err != nil {
 			return nil, "", nil, err
 		}
@@ -63,63 +95,461 @@ func (o *userBuilder) List(ctx context.Context, _ *v2.ResourceId, pToken *pagina
 	return resources, nextPageToken, nil, nil
 }
 
-// Entitlements always returns an empty slice for users.
-func (o *userBuilder) Entitlements(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
-	return nil, "", nil, nil
+// Entitlements returns the manager entitlement of a user, which is granted to their direct reports.
+func (o *userBuilder) Entitlements(_ context.Context, res *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	managerOptions := []entitlement.EntitlementOption{
+		entitlement.WithGrantableTo(userResourceType),
+		entitlement.WithDescription(fmt.Sprintf("Reports to %s", res.DisplayName)),
+		entitlement.WithDisplayName(fmt.Sprintf("%s direct report", res.DisplayName)),
+	}
+
+	return []*v2.Entitlement{
+		entitlement.NewAssignmentEntitlement(res, userManagerEntitlement, managerOptions...),
+	}, "", nil, nil
 }
 
-// Grants always returns an empty slice for users since they don't have any entitlements.
+// Grants grants the manager entitlement of the user's manager to the user. Role assignments are
+// granted by the role builder.
 func (o *userBuilder) Grants(ctx context.Context, res *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
-	var grants []*v2.Grant
+	managerGrant, err := getManagerGrant(res)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if managerGrant == nil {
+		return nil, "", nil, nil
+	}
+
+	inScope, err := o.employees.InScope(ctx, managerGrant.Entitlement.Resource.Id.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if !inScope {
+		return nil, "", nil, nil
+	}
 
-	var userID = res.Id.Resource
+	return []*v2.Grant{managerGrant}, "", nil, nil
+}
 
-	employees, _, _, err := o.client.GetEmployeeByID(ctx, userID)
+// CreateAccountCapabilityDetails advertises account creation without credentials, Zoho People
+// invites new employees to sign in themselves.
+func (o *userBuilder) CreateAccountCapabilityDetails(_ context.Context) (*v2.CredentialDetailsAccountProvisioning, annotations.Annotations, error) {
+	return &v2.CredentialDetailsAccountProvisioning{
+		SupportedCredentialOptions: []v2.CapabilityDetailCredentialOption{
+			v2.CapabilityDetailCredentialOption_CAPABILITY_DETAIL_CREDENTIAL_OPTION_NO_PASSWORD,
+		},
+		PreferredCredentialOption: v2.CapabilityDetailCredentialOption_CAPABILITY_DETAIL_CREDENTIAL_OPTION_NO_PASSWORD,
+	}, nil, nil
+}
+
+// CreateAccount adds an employee record from the account profile, see accountCreationSchema for the fields.
+func (o *userBuilder) CreateAccount(
+	ctx context.Context,
+	accountInfo *v2.AccountInfo,
+	credentialOptions *v2.CredentialOptions,
+) (connectorbuilder.CreateAccountResponse, []*v2.PlaintextData, annotations.Annotations, error) {
+	if credentialOptions != nil && credentialOptions.GetNoPassword() == nil {
+		return nil, nil, nil, status.Error(codes.InvalidArgument, "baton-zoho-people: only accounts without a password can be created")
+	}
 
+	fields, err := getAccountFields(accountInfo)
 	if err != nil {
-		return nil, "", nil, err
+		return nil, nil, nil, err
 	}
 
-	for _, employee := range employees {
-		roleName := employee.Role
-		if roleName != "" {
-			roleResource := &v2.Resource{
-				Id: &v2.ResourceId{
-					ResourceType: roleResourceType.Id,
-					Resource:     GetRoleID(roleName),
-				},
+	recordID, annos, err := o.client.InsertEmployee(ctx, fields)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	o.employees.Reset()
+
+	employee, err := getEmployee(ctx, o.client, recordID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("baton-zoho-people: employee %s was created but could not be read back: %w", recordID, err)
+	}
+
+	userResource, err := parseIntoUserResource(employee, recordID, o.profileFields)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return &v2.CreateAccountResponse_SuccessResult{
+		Resource:              userResource,
+		IsCreateAccountResult: true,
+	}, nil, annos, nil
+}
+
+// Create adds an employee record the same way CreateAccount does, reading the account profile and
+// emails from the user trait of the resource. The SDK only offers user deletion together with
+// Create, so this keeps the two paths to a new employee consistent.
+func (o *userBuilder) Create(ctx context.Context, res *v2.Resource) (*v2.Resource, annotations.Annotations, error) {
+	if res.GetId().GetResourceType() != userResourceType.Id {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "baton-zoho-people: expected a user, got %s", res.GetId().GetResourceType())
+	}
+
+	userTrait, err := resource.GetUserTrait(res)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "baton-zoho-people: the user has no user trait: %v", err)
+	}
+
+	accountInfo := &v2.AccountInfo{
+		Login:   userTrait.GetLogin(),
+		Profile: userTrait.GetProfile(),
+	}
+	for _, email := range userTrait.GetEmails() {
+		accountInfo.Emails = append(accountInfo.Emails, &v2.AccountInfo_Email{
+			Address:   email.GetAddress(),
+			IsPrimary: email.GetIsPrimary(),
+		})
+	}
+	result, _, annos, err := o.CreateAccount(ctx, accountInfo, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	success, ok := result.(*v2.CreateAccountResponse_SuccessResult)
+	if !ok {
+		return nil, nil, status.Error(codes.Internal, "baton-zoho-people: unexpected account creation result")
+	}
+	return success.GetResource(), annos, nil
+}
+
+// Delete offboards the employee. Zoho People keeps the records of former employees, so the employee
+// is marked as exited instead, optionally moving them to the default role and clearing their manager.
+// Offboarding an employee who already left only applies the remaining changes.
+func (o *userBuilder) Delete(ctx context.Context, resourceId *v2.ResourceId) (annotations.Annotations, error) {
+	if resourceId.ResourceType != userResourceType.Id {
+		return nil, status.Errorf(codes.InvalidArgument, "baton-zoho-people: expected a user, got %s", resourceId.ResourceType)
+	}
+
+	employee, err := getEmployee(ctx, o.client, resourceId.Resource)
+	if err != nil {
+		if client.IsNoRecords(err) || status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	fields := getOffboardingFields(employee, o.offboarding, time.Now())
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	annos, err := o.client.UpdateEmployee(ctx, resourceId.Resource, fields)
+	if err != nil {
+		return nil, err
+	}
+	o.employees.Reset()
+
+	return annos, nil
+}
+
+// getOffboardingFields returns the employee fields that still need to change to offboard the employee.
+func getOffboardingFields(employee *client.Employee, opts offboarding, now time.Time) map[string]string {
+	fields := make(map[string]string)
+
+	if getUserStatus(employee, now) == v2.UserTrait_Status_STATUS_ENABLED {
+		fields["Employeestatus"] = opts.status
+		if exitDate, ok := parseZohoDate(employee.DateOfExit); !ok || exitDate.After(now) {
+			fields["Dateofexit"] = now.Format(zohoDateLayouts[0])
+		}
+	}
+
+	if opts.roleID != "" && employee.RoleID != opts.roleID {
+		fields["Role"] = opts.roleID
+	}
+
+	if opts.clearManager && employee.ReportingToID != "" {
+		fields["Reporting_To"] = ""
+	}
+
+	return fields
+}
+
+// getAccountFields maps the account profile to the fields of the Zoho employee form.
+func getAccountFields(accountInfo *v2.AccountInfo) (map[string]string, error) {
+	profile := accountInfo.GetProfile()
+
+	email, _ := resource.GetProfileStringValue(profile, "email")
+	if email == "" {
+		for _, e := range accountInfo.GetEmails() {
+			if email == "" || e.GetIsPrimary() {
+				email = e.GetAddress()
 			}
-			employeeCopy := employee
-			userResource, _ := parseIntoUserResource(&employeeCopy, userID)
-			userGrant := grant.NewGrant(roleResource, "assigned", userResource, grant.WithAnnotation(&v2.V1Identifier{
-				Id: fmt.Sprintf("role-grant:%s:%s:%s", GetRoleID(roleName), userID, "assigned"),
-			}))
-			grants = append(grants, userGrant)
 		}
 	}
+	if email == "" && strings.Contains(accountInfo.GetLogin(), "@") {
+		email = accountInfo.GetLogin()
+	}
+
+	fields := map[string]string{
+		"EmailID": email,
+	}
+	for key, label := range map[string]string{
+		"first_name":  "FirstName",
+		"last_name":   "LastName",
+		"employee_id": "EmployeeID",
+	} {
+		value, _ := resource.GetProfileStringValue(profile, key)
+		fields[label] = strings.TrimSpace(value)
+	}
+
+	for label, value := range fields {
+		if value == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "baton-zoho-people: %s is required to create an employee", label)
+		}
+	}
+
+	// Lookup fields take the ID of the referenced record.
+	for key, label := range map[string]string{
+		"department_id": "Department",
+		"role_id":       "Role",
+		"manager_id":    "Reporting_To",
+	} {
+		if value, ok := resource.GetProfileStringValue(profile, key); ok && value != "" {
+			fields[label] = value
+		}
+	}
+
+	if value, ok := resource.GetProfileStringValue(profile, "joining_date"); ok && value != "" {
+		joiningDate, ok := parseZohoDate(value)
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "baton-zoho-people: invalid joining date %q, expected YYYY-MM-DD", value)
+		}
+		fields["Dateofjoining"] = joiningDate.Format(zohoDateLayouts[0])
+	}
 
-	return grants, "", nil, nil
+	return fields, nil
 }
 
-func parseIntoUserResource(user *client.Employee, zohoID string) (*v2.Resource, error) {
-	var userStatus = v2.UserTrait_Status_STATUS_ENABLED
+// getManagerGrant grants the manager entitlement of the user's manager to the user.
+// The manager is resolved from the user profile, so no extra API call is needed.
+func getManagerGrant(res *v2.Resource) (*v2.Grant, error) {
+	userTrait, err := resource.GetUserTrait(res)
+	if err != nil {
+		return nil, err
+	}
+
+	managerID, ok := resource.GetProfileStringValue(userTrait.Profile, "manager_id")
+	if !ok || managerID == "" || managerID == res.Id.Resource {
+		return nil, nil
+	}
+
+	managerResource := &v2.Resource{
+		Id: &v2.ResourceId{
+			ResourceType: userResourceType.Id,
+			Resource:     managerID,
+		},
+	}
+
+	return grant.NewGrant(managerResource, userManagerEntitlement, res.Id, grant.WithAnnotation(&v2.V1Identifier{
+		Id: fmt.Sprintf("manager-grant:%s:%s:%s", managerID, res.Id.Resource, userManagerEntitlement),
+	})), nil
+}
+
+// Zoho People shows dates in the format configured for the organization, dd-MMM-yyyy by default.
+var zohoDateLayouts = []string{"02-Jan-2006", "2006-01-02", "02-01-2006"}
+
+// employeeStatusActiveType is the Employeestatus.type of statuses that count as active in Zoho People.
+const employeeStatusActiveType = 1
+
+// getUserStatus maps the Zoho employee status to the user trait status. Known status names win,
+// custom statuses fall back to the status type, and an exit date in the past always disables the user.
+func getUserStatus(user *client.Employee, now time.Time) v2.UserTrait_Status_Status {
+	status := v2.UserTrait_Status_STATUS_ENABLED
+
+	switch strings.ToLower(strings.TrimSpace(user.EmployeeStatus)) {
+	case "active":
+	case "deceased", "deleted":
+		return v2.UserTrait_Status_STATUS_DELETED
+	case "resigned", "terminated", "inactive", "exited":
+		status = v2.UserTrait_Status_STATUS_DISABLED
+	default:
+		if user.EmployeeStatusType != 0 && user.EmployeeStatusType != employeeStatusActiveType {
+			status = v2.UserTrait_Status_STATUS_DISABLED
+		}
+	}
+
+	if exitDate, ok := parseZohoDate(user.DateOfExit); ok && !exitDate.After(now) {
+		status = v2.UserTrait_Status_STATUS_DISABLED
+	}
+
+	return status
+}
+
+func parseZohoDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	for _, layout := range zohoDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// parseZohoTimestamp parses the epoch milliseconds Zoho uses for CreatedTime and ModifiedTime.
+func parseZohoTimestamp(value string) (time.Time, bool) {
+	ms, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || ms <= 0 {
+		return time.Time{}, false
+	}
+
+	return time.UnixMilli(ms).UTC(), true
+}
+
+// getUserLogin returns the work email as login, with the employee ID and ZUID as aliases.
+// Zoho sets the ZUID to -1 for employees without a Zoho account.
+func getUserLogin(user *client.Employee, fallback string) (string, []string) {
+	var candidates []string
+	for _, value := range []string{user.EmailID, user.EmployeeID, user.ZUID} {
+		value = strings.TrimSpace(value)
+		if value == "" || strings.HasPrefix(value, "-") {
+			continue
+		}
+		candidates = append(candidates, value)
+	}
+
+	if len(candidates) == 0 {
+		return fallback, nil
+	}
+
+	return candidates[0], candidates[1:]
+}
+
+func getUserEmails(user *client.Employee) []resource.UserTraitOption {
+	var options []resource.UserTraitOption
+	if email := strings.TrimSpace(user.EmailID); email != "" {
+		options = append(options, resource.WithEmail(email, true))
+	}
+
+	for _, email := range strings.Split(user.OtherEmail, ",") {
+		email = strings.TrimSpace(email)
+		if email == "" || strings.EqualFold(email, user.EmailID) {
+			continue
+		}
+		options = append(options, resource.WithEmail(email, false))
+	}
+
+	return options
+}
+
+// addMappedProfileFields copies the Zoho fields configured in profileFields, keyed by field label,
+// into the profile. The built-in profile keys cannot be replaced.
+func addMappedProfileFields(profile map[string]interface{}, user *client.Employee, profileFields map[string]string) {
+	for label, key := range profileFields {
+		if _, ok := profile[key]; ok {
+			continue
+		}
+
+		raw, ok := user.Fields[label]
+		if !ok {
+			continue
+		}
+
+		if value, ok := rawProfileValue(raw); ok {
+			profile[key] = value
+		}
+	}
+}
+
+// rawProfileValue converts a raw Zoho field value into a profile value. Numbers are kept as strings
+// so large record IDs do not lose precision, objects and arrays are kept as JSON.
+func rawProfileValue(raw json.RawMessage) (interface{}, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, false
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil, false
+	case json.Number:
+		return v.String(), true
+	case string, bool:
+		return v, true
+	default:
+		return string(raw), true
+	}
+}
+
+// getPhotoFileName returns the file name Zoho stores the employee photo under, taken from Photo_downloadUrl.
+func getPhotoFileName(user *client.Employee) string {
+	if user.PhotoDownloadUrl == "" {
+		return ""
+	}
+
+	photoURL, err := url.Parse(user.PhotoDownloadUrl)
+	if err != nil {
+		return ""
+	}
+
+	return photoURL.Query().Get("filename")
+}
+
+func parseIntoUserResource(user *client.Employee, zohoID string, profileFields map[string]string) (*v2.Resource, error) {
+	var userStatus = getUserStatus(user, time.Now())
 
 	profile := map[string]interface{}{
-		"employee_id": user.EmployeeID,
-		"first_name":  user.FirstName,
-		"last_name":   user.LastName,
-		"email_id":    user.EmailID,
-		"zuid":        user.ZUID,
+		"employee_id":          user.EmployeeID,
+		"first_name":           user.FirstName,
+		"last_name":            user.LastName,
+		"email_id":             user.EmailID,
+		"zuid":                 user.ZUID,
+		"manager_id":           user.ReportingToID,
+		"manager_name":         user.ReportingTo,
+		"manager_email":        user.ReportingToMailID,
+		"employee_status":      user.EmployeeStatus,
+		"employee_status_type": int64(user.EmployeeStatusType),
+		"date_of_exit":         user.DateOfExit,
+		"other_email":          user.OtherEmail,
+		"employee_type":        user.EmployeeType,
+		"designation":          user.Designation,
+		"work_location":        user.WorkLocation,
+		"location_name":        user.LocationName,
+		"created_time":         user.CreatedTime,
 	}
 	displayName := fmt.Sprintf("%s %s", user.FirstName, user.LastName)
 	userID := zohoID
 	if user.ZohoID != 0 {
-		userID = strconv.FormatInt(user.ZohoID, 10)
+		userID = user.ZohoID.String()
 	}
+	if joiningDate, ok := parseZohoDate(user.DateOfJoining); ok {
+		profile["joining_date"] = joiningDate.Format(time.DateOnly)
+	}
+	addMappedProfileFields(profile, user, profileFields)
+
+	login, loginAliases := getUserLogin(user, userID)
+	var middleNames []string
+	if user.MiddleName != "" {
+		middleNames = append(middleNames, user.MiddleName)
+	}
+
 	userTraits := []resource.UserTraitOption{
 		resource.WithUserProfile(profile),
 		resource.WithStatus(userStatus),
-		resource.WithUserLogin(displayName),
+		resource.WithUserLogin(login, loginAliases...),
+		resource.WithAccountType(v2.UserTrait_ACCOUNT_TYPE_HUMAN),
+		resource.WithStructuredName(&v2.UserTrait_StructuredName{
+			GivenName:   user.FirstName,
+			FamilyName:  user.LastName,
+			MiddleNames: middleNames,
+		}),
+	}
+	userTraits = append(userTraits, getUserEmails(user)...)
+
+	if createdAt, ok := parseZohoTimestamp(user.CreatedTime); ok {
+		userTraits = append(userTraits, resource.WithCreatedAt(createdAt))
+	}
+
+	if photoFileName := getPhotoFileName(user); photoFileName != "" {
+		userTraits = append(userTraits, resource.WithUserIcon(&v2.AssetRef{Id: photoFileName}))
 	}
 
 	ret, err := resource.NewUserResource(
@@ -135,9 +565,16 @@ func parseIntoUserResource(user *client.Employee, zohoID string) (*v2.Resource,
 	return ret, nil
 }
 
-func newUserBuilder(c *client.ZohoPeopleClient) *userBuilder {
+func newUserBuilder(c *client.ZohoPeopleClient, employees *employeeCache, profileFields map[string]string, offboarding offboarding) *userBuilder {
+	if offboarding.status == "" {
+		offboarding.status = defaultOffboardStatus
+	}
+
 	return &userBuilder{
-		resourceType: userResourceType,
-		client:       c,
+		resourceType:  userResourceType,
+		client:        c,
+		employees:     employees,
+		profileFields: profileFields,
+		offboarding:   offboarding,
 	}
 }
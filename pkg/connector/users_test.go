@@ -2,16 +2,28 @@ package connector
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/resource"
 	"github.com/conductorone/baton-sdk/pkg/uhttp"
 	"github.com/conductorone/baton-zoho-people/pkg/client"
 	"github.com/conductorone/baton-zoho-people/test"
+	"github.com/conductorone/baton-zoho-people/test/mock"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/oauth2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 var pageOptions = client.PageOptions{
@@ -54,7 +66,7 @@ func TestZohoPeopleClient_GetUsers(t *testing.T) {
 
 	for index, user := range result {
 		expectedUser := client.Employee{
-			ZohoID:     int64(index + 10000000000),
+			ZohoID:     client.FlexInt64(index + 10000000000),
 			FirstName:  test.Employees[index]["firstName"],
 			LastName:   test.Employees[index]["lastName"],
 			EmployeeID: test.Employees[index]["employeeID"],
@@ -137,3 +149,399 @@ func TestZohoPeopleClient_GetUsers_RequestDetails(t *testing.T) {
 		}
 	}
 }
+
+// Tests that users carry their manager and are granted the manager entitlement of that manager.
+func TestUserBuilder_ManagerGrant(t *testing.T) {
+	ctx := context.Background()
+
+	mockResponse := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader(test.ReadFile("employeesMock.json"))),
+	}
+	mockResponse.Header.Set("Content-Type", "application/json")
+
+	testClient := test.NewTestClient(mockResponse, nil)
+	u := newUserBuilder(testClient, newEmployeeCache(testClient), nil, offboarding{})
+	users, _, _, err := u.List(ctx, nil, &pagination.Token{Size: 10})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(users) == 0 {
+		t.Fatal("Expected users")
+	}
+
+	userTrait, err := resource.GetUserTrait(users[0])
+	if err != nil {
+		t.Fatalf("Expected a user trait, got %v", err)
+	}
+	if email, _ := resource.GetProfileStringValue(userTrait.Profile, "manager_email"); email != "admin@example.com" {
+		t.Errorf("Expected manager email admin@example.com, got %q", email)
+	}
+
+	managerGrant, err := getManagerGrant(users[0])
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if managerGrant == nil {
+		t.Fatal("Expected a manager grant")
+	}
+	if managerGrant.Entitlement.Resource.Id.Resource != "858578000000275005" {
+		t.Errorf("Expected the grant on the manager, got %v", managerGrant.Entitlement.Resource.Id)
+	}
+	if managerGrant.Principal.Id.Resource != users[0].Id.Resource {
+		t.Errorf("Expected the grant to the direct report, got %v", managerGrant.Principal.Id)
+	}
+
+	entitlements, _, _, err := u.Entitlements(ctx, users[0], &pagination.Token{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(entitlements) != 1 || entitlements[0].Slug != userManagerEntitlement {
+		t.Errorf("Expected only the manager entitlement, got %v", entitlements)
+	}
+}
+
+func TestGetUserStatus(t *testing.T) {
+	now := time.Date(2024, time.June, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		employee client.Employee
+		want     v2.UserTrait_Status_Status
+	}{
+		{
+			name:     "active",
+			employee: client.Employee{EmployeeStatus: "Active", EmployeeStatusType: 1},
+			want:     v2.UserTrait_Status_STATUS_ENABLED,
+		},
+		{
+			name:     "resigned",
+			employee: client.Employee{EmployeeStatus: "Resigned", EmployeeStatusType: 2},
+			want:     v2.UserTrait_Status_STATUS_DISABLED,
+		},
+		{
+			name:     "terminated",
+			employee: client.Employee{EmployeeStatus: "Terminated"},
+			want:     v2.UserTrait_Status_STATUS_DISABLED,
+		},
+		{
+			name:     "deceased",
+			employee: client.Employee{EmployeeStatus: "Deceased"},
+			want:     v2.UserTrait_Status_STATUS_DELETED,
+		},
+		{
+			name:     "custom inactive status",
+			employee: client.Employee{EmployeeStatus: "Long leave", EmployeeStatusType: 2},
+			want:     v2.UserTrait_Status_STATUS_DISABLED,
+		},
+		{
+			name:     "custom active status",
+			employee: client.Employee{EmployeeStatus: "Probation", EmployeeStatusType: 1},
+			want:     v2.UserTrait_Status_STATUS_ENABLED,
+		},
+		{
+			name:     "exit date passed",
+			employee: client.Employee{EmployeeStatus: "Active", EmployeeStatusType: 1, DateOfExit: "01-Jun-2024"},
+			want:     v2.UserTrait_Status_STATUS_DISABLED,
+		},
+		{
+			name:     "exit date ahead",
+			employee: client.Employee{EmployeeStatus: "Active", EmployeeStatusType: 1, DateOfExit: "2024-07-01"},
+			want:     v2.UserTrait_Status_STATUS_ENABLED,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getUserStatus(&tt.employee, now); got != tt.want {
+				t.Errorf("getUserStatus() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Tests that the user trait carries the work email as login and the identifiers Zoho knows the employee by.
+func TestParseIntoUserResource_Trait(t *testing.T) {
+	employee := client.Employee{
+		ZohoID:        100000000000,
+		FirstName:     "Christopher",
+		MiddleName:    "John",
+		LastName:      "Brown",
+		EmailID:       "christopherbrown@zylker.com",
+		OtherEmail:    "chris@example.com",
+		EmployeeID:    "S20",
+		ZUID:          "-1",
+		EmployeeType:  "Permanent",
+		CreatedTime:   "1740682258538",
+		DateOfJoining: "05-Jan-2015",
+
+		PhotoDownloadUrl: "https://people.zoho.com/api/viewEmployeePhoto?filename=example1",
+	}
+
+	userResource, err := parseIntoUserResource(&employee, "", nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	userTrait, err := resource.GetUserTrait(userResource)
+	if err != nil {
+		t.Fatalf("Expected a user trait, got %v", err)
+	}
+
+	if userTrait.Login != "christopherbrown@zylker.com" {
+		t.Errorf("Expected the work email as login, got %q", userTrait.Login)
+	}
+	if !reflect.DeepEqual(userTrait.LoginAliases, []string{"S20"}) {
+		t.Errorf("Expected the employee ID as the only login alias, got %v", userTrait.LoginAliases)
+	}
+	if len(userTrait.Emails) != 2 || !userTrait.Emails[0].IsPrimary || userTrait.Emails[1].Address != "chris@example.com" {
+		t.Errorf("Unexpected emails %v", userTrait.Emails)
+	}
+	if userTrait.StructuredName.GetGivenName() != "Christopher" || userTrait.StructuredName.GetFamilyName() != "Brown" {
+		t.Errorf("Unexpected structured name %v", userTrait.StructuredName)
+	}
+	if userTrait.CreatedAt.AsTime().UnixMilli() != 1740682258538 {
+		t.Errorf("Unexpected creation time %v", userTrait.CreatedAt.AsTime())
+	}
+	if userTrait.Icon.GetId() != "example1" {
+		t.Errorf("Expected the photo file name as icon asset, got %v", userTrait.Icon)
+	}
+	if joiningDate, _ := resource.GetProfileStringValue(userTrait.Profile, "joining_date"); joiningDate != "2015-01-05" {
+		t.Errorf("Expected joining date 2015-01-05, got %q", joiningDate)
+	}
+	if _, ok := userTrait.Profile.GetFields()["date_of_joining"]; ok {
+		t.Error("Expected the joining date to be stored once, as joining_date")
+	}
+}
+
+// Tests that mapped Zoho fields, including custom fields, are copied into the user profile.
+func TestParseIntoUserResource_ProfileFieldMapping(t *testing.T) {
+	var employee client.Employee
+	err := json.Unmarshal([]byte(`{
+		"Zoho_ID": 100000000000,
+		"FirstName": "Christopher",
+		"EmployeeID": "S20",
+		"Cost_Center": "CC-42",
+		"Badge_ID": 1234567890123456789,
+		"Legal_Entity": {"name": "Zylker Inc."}
+	}`), &employee)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	userResource, err := parseIntoUserResource(&employee, "", map[string]string{
+		"Cost_Center":  "cost_center",
+		"Badge_ID":     "badge_id",
+		"Legal_Entity": "legal_entity",
+		"Missing":      "missing",
+		"EmployeeID":   "first_name",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	userTrait, err := resource.GetUserTrait(userResource)
+	if err != nil {
+		t.Fatalf("Expected a user trait, got %v", err)
+	}
+
+	expected := map[string]string{
+		"cost_center":  "CC-42",
+		"badge_id":     "1234567890123456789",
+		"legal_entity": `{"name": "Zylker Inc."}`,
+		"first_name":   "Christopher",
+	}
+	for key, want := range expected {
+		if got, _ := resource.GetProfileStringValue(userTrait.Profile, key); got != want {
+			t.Errorf("Expected profile %s to be %q, got %q", key, want, got)
+		}
+	}
+	if _, ok := userTrait.Profile.GetFields()["missing"]; ok {
+		t.Error("Expected fields missing from the record to be skipped")
+	}
+}
+
+func TestUserBuilder_CreateAccount(t *testing.T) {
+	ctx := context.Background()
+
+	var updates []url.Values
+	testClient := recordingClient(
+		`{"Zoho_ID":100000000002,"FirstName":"Ada","LastName":"Lovelace","EmailID":"ada@zylker.com","EmployeeID":"S21"}`,
+		`{"response":{"result":{"pkId":"100000000002","message":"Successfully Added"},"message":"Data added successfully","status":0}}`,
+		&updates,
+	)
+	u := newUserBuilder(testClient, newEmployeeCache(testClient), nil, offboarding{})
+
+	profile, err := structpb.NewStruct(map[string]interface{}{
+		"first_name":    "Ada",
+		"last_name":     "Lovelace",
+		"employee_id":   "S21",
+		"department_id": "858578000000277092",
+		"joining_date":  "2025-03-01",
+	})
+	require.NoError(t, err)
+
+	accountInfo := &v2.AccountInfo{
+		Emails:  []*v2.AccountInfo_Email{{Address: "ada@zylker.com", IsPrimary: true}},
+		Profile: profile,
+	}
+	noPassword := &v2.CredentialOptions{Options: &v2.CredentialOptions_NoPassword_{NoPassword: &v2.CredentialOptions_NoPassword{}}}
+
+	res, _, _, err := u.CreateAccount(ctx, accountInfo, noPassword)
+	require.NoError(t, err)
+	require.True(t, res.GetIsCreateAccountResult())
+
+	success, ok := res.(*v2.CreateAccountResponse_SuccessResult)
+	require.True(t, ok)
+	require.Equal(t, "100000000002", success.Resource.Id.Resource)
+	require.Equal(t, "Ada Lovelace", success.Resource.DisplayName)
+
+	require.Len(t, updates, 1)
+	require.JSONEq(t, `{
+		"FirstName": "Ada",
+		"LastName": "Lovelace",
+		"EmailID": "ada@zylker.com",
+		"EmployeeID": "S21",
+		"Department": "858578000000277092",
+		"Dateofjoining": "01-Mar-2025"
+	}`, updates[0].Get("inputData"))
+
+	delete(profile.Fields, "employee_id")
+	_, _, _, err = u.CreateAccount(ctx, accountInfo, noPassword)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// Tests that creating a user resource adds the employee through the account creation path.
+func TestUserBuilder_Create(t *testing.T) {
+	ctx := context.Background()
+
+	var updates []url.Values
+	testClient := recordingClient(
+		`{"Zoho_ID":100000000002,"FirstName":"Ada","LastName":"Lovelace","EmailID":"ada@zylker.com","EmployeeID":"S21"}`,
+		`{"response":{"result":{"pkId":"100000000002","message":"Successfully Added"},"message":"Data added successfully","status":0}}`,
+		&updates,
+	)
+	u := newUserBuilder(testClient, newEmployeeCache(testClient), nil, offboarding{})
+
+	user, err := resource.NewUserResource("Ada Lovelace", userResourceType, "new", []resource.UserTraitOption{
+		resource.WithEmail("ada@zylker.com", true),
+		resource.WithUserProfile(map[string]interface{}{
+			"first_name":  "Ada",
+			"last_name":   "Lovelace",
+			"employee_id": "S21",
+		}),
+	})
+	require.NoError(t, err)
+
+	created, _, err := u.Create(ctx, user)
+	require.NoError(t, err)
+	require.Equal(t, "100000000002", created.Id.Resource)
+	require.Len(t, updates, 1)
+	require.JSONEq(t, `{
+		"FirstName": "Ada",
+		"LastName": "Lovelace",
+		"EmailID": "ada@zylker.com",
+		"EmployeeID": "S21"
+	}`, updates[0].Get("inputData"))
+
+	_, _, err = u.Create(ctx, &v2.Resource{Id: &v2.ResourceId{ResourceType: userResourceType.Id, Resource: "new"}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestUserBuilder_Delete(t *testing.T) {
+	ctx := context.Background()
+
+	const defaultRoleID = "858578000000035645"
+	userID := &v2.ResourceId{ResourceType: userResourceType.Id, Resource: "100000000000"}
+
+	newBuilder := func(testClient *client.ZohoPeopleClient, opts offboarding) *userBuilder {
+		return newUserBuilder(testClient, newEmployeeCache(testClient), nil, opts)
+	}
+
+	t.Run("offboard", func(t *testing.T) {
+		var updates []url.Values
+		record := `{"Zoho_ID":100000000000,"Employeestatus":"Active","Employeestatus.type":1,` +
+			`"Role.ID":"858578000000035639","Reporting_To.ID":"100000000001"}`
+		opts := offboarding{status: "Resigned", roleID: defaultRoleID, clearManager: true}
+		_, err := newBuilder(recordingClient(record, "", &updates), opts).Delete(ctx, userID)
+		require.NoError(t, err)
+		require.Len(t, updates, 1)
+		require.Equal(t, "100000000000", updates[0].Get("recordId"))
+		require.JSONEq(t, fmt.Sprintf(`{
+			"Employeestatus": "Resigned",
+			"Dateofexit": %q,
+			"Role": %q,
+			"Reporting_To": ""
+		}`, time.Now().Format("02-Jan-2006"), defaultRoleID), updates[0].Get("inputData"))
+	})
+
+	t.Run("default status", func(t *testing.T) {
+		var updates []url.Values
+		_, err := newBuilder(recordingClient(`{"Zoho_ID":100000000000,"Employeestatus":"Active","Employeestatus.type":1}`, "", &updates), offboarding{}).Delete(ctx, userID)
+		require.NoError(t, err)
+		require.Len(t, updates, 1)
+		require.Contains(t, updates[0].Get("inputData"), `"Employeestatus":"Terminated"`)
+	})
+
+	t.Run("already exited", func(t *testing.T) {
+		var updates []url.Values
+		record := `{"Zoho_ID":100000000000,"Employeestatus":"Resigned","Employeestatus.type":0,` +
+			`"Dateofexit":"01-Mar-2025","Role.ID":"858578000000035645"}`
+		opts := offboarding{status: "Resigned", roleID: defaultRoleID, clearManager: true}
+		_, err := newBuilder(recordingClient(record, "", &updates), opts).Delete(ctx, userID)
+		require.NoError(t, err)
+		require.Empty(t, updates)
+	})
+}
+
+// formsClient answers getRecords with the employee or department records and records the
+// searchParams filters.
+func formsClient(employees, departments []string, searches *[]string) *client.ZohoPeopleClient {
+	return test.NewMockClient(func(req *http.Request) (*http.Response, error) {
+		records := employees
+		if strings.Contains(req.URL.Path, "/department/") {
+			records = departments
+		}
+		*searches = append(*searches, req.URL.Query().Get("searchParams"))
+
+		if sIndex := req.URL.Query().Get("sIndex"); (sIndex != "" && sIndex != "1") || len(records) == 0 {
+			return mock.JSONResponse(`{"response":{"errors":{"code":7024,"message":"No records found"},"status":1}}`), nil
+		}
+		var result []string
+		for i, record := range records {
+			result = append(result, fmt.Sprintf(`{"%d":[%s]}`, i, record))
+		}
+		return mock.JSONResponse(fmt.Sprintf(`{"response":{"result":[%s],"status":0}}`, strings.Join(result, ","))), nil
+	})
+}
+
+// Tests that manager grants are left out when the manager is outside of the sync scope.
+func TestUserBuilder_GrantsOutOfScopeManager(t *testing.T) {
+	ctx := context.Background()
+
+	// Zoho only returns the employees matching the search criteria.
+	var searches []string
+	testClient := formsClient([]string{
+		`{"Zoho_ID":1,"FirstName":"Christopher","Employeestatus":"Active","Reporting_To.ID":"2"}`,
+		`{"Zoho_ID":3,"FirstName":"Emma","Employeestatus":"Active","Reporting_To.ID":"1"}`,
+	}, nil, &searches)
+	employees := newEmployeeCache(testClient)
+	employees.scope = syncScope{employees: []client.SearchParam{{Field: "Employeestatus", Operator: "Is", Text: "Active"}}}
+	u := newUserBuilder(testClient, employees, nil, offboarding{})
+
+	users, _, _, err := u.List(ctx, nil, &pagination.Token{Size: 10})
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	for _, search := range searches {
+		require.Equal(t, "{searchField:'Employeestatus',searchOperator:'Is',searchText:'Active'}", search)
+	}
+
+	grants := make(map[string]int)
+	for _, user := range users {
+		userGrants, _, _, err := u.Grants(ctx, user, &pagination.Token{})
+		require.NoError(t, err)
+		grants[user.Id.Resource] = len(userGrants)
+	}
+	require.Equal(t, map[string]int{"1": 0, "3": 1}, grants)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/conductorone/baton-sdk/pkg/uhttp"
 	"github.com/conductorone/baton-zoho-people/pkg/client"
+	"github.com/conductorone/baton-zoho-people/test/mock"
 	"golang.org/x/oauth2"
 )
 
@@ -62,6 +63,14 @@ func NewTestClient(response *http.Response, err error) *client.ZohoPeopleClient
 	return client.NewClient(oauth2.StaticTokenSource(&token), baseHttpClient)
 }
 
+// NewMockClient returns a client that answers every request with roundTrip.
+func NewMockClient(roundTrip func(*http.Request) (*http.Response, error)) *client.ZohoPeopleClient {
+	token := oauth2.Token{
+		AccessToken: "token",
+	}
+	return client.NewClient(oauth2.StaticTokenSource(&token), mock.HTTPClient(roundTrip))
+}
+
 func ReadFile(fileName string) string {
 	data, err := os.ReadFile("../../test/mockResponses/" + fileName)
 	if err != nil {
@@ -0,0 +1,48 @@
+// Package mock answers the HTTP requests of the Zoho People client in tests. It does not import the
+// client, so the tests of the client package can use it too.
+package mock
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+)
+
+// Transport answers every request with its round trip function.
+type Transport struct {
+	roundTrip func(*http.Request) (*http.Response, error)
+}
+
+// NewTransport returns a transport that answers every request with roundTrip.
+func NewTransport(roundTrip func(*http.Request) (*http.Response, error)) *Transport {
+	return &Transport{roundTrip: roundTrip}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.roundTrip(req)
+}
+
+// HTTPClient returns an HTTP client that answers every request with roundTrip.
+func HTTPClient(roundTrip func(*http.Request) (*http.Response, error)) *uhttp.BaseHttpClient {
+	return uhttp.NewBaseHttpClient(&http.Client{Transport: NewTransport(roundTrip)})
+}
+
+// Response returns a successful response with the body and content type.
+func Response(contentType, body string) *http.Response {
+	header := make(http.Header)
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+// JSONResponse returns a successful JSON response with the body.
+func JSONResponse(body string) *http.Response {
+	return Response("application/json", body)
+}